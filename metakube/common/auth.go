@@ -8,10 +8,8 @@ import (
 
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
-	"github.com/hashicorp/go-cty/cty"
 	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
-	sdkdiag "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/mitchellh/go-homedir"
 )
 
@@ -31,14 +29,29 @@ func NewAuthError(message string, attributes ...string) *AuthError {
 	}
 }
 
-func NewAuth(token, tokenPath, terraformVersion string) (runtime.ClientAuthInfoWriter, error) {
-	resolvedToken, err := resolveToken(token, tokenPath)
+// NewAuth returns a ClientAuthInfoWriter using the first of token, tokenCommand
+// and tokenPath that is set. A token obtained from tokenCommand is cached until
+// it expires, after which the command is run again.
+func NewAuth(token, tokenPath string, tokenCommand []string, terraformVersion string) (runtime.ClientAuthInfoWriter, error) {
+	var command *TokenCommand
+	if len(tokenCommand) > 0 {
+		command = NewTokenCommand(tokenCommand)
+	}
+
+	resolvedToken, err := resolveToken(token, tokenPath, command)
 	if err != nil {
 		return nil, err
 	}
 
 	auth := runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
-		if err := r.SetHeaderParam("Authorization", "Bearer "+resolvedToken); err != nil {
+		currentToken := resolvedToken
+		if token == "" && command != nil {
+			var err error
+			if currentToken, err = resolveToken(token, tokenPath, command); err != nil {
+				return err
+			}
+		}
+		if err := r.SetHeaderParam("Authorization", "Bearer "+currentToken); err != nil {
 			return err
 		}
 		return r.SetHeaderParam("User-Agent", fmt.Sprintf("Terraform/%s", terraformVersion))
@@ -47,11 +60,15 @@ func NewAuth(token, tokenPath, terraformVersion string) (runtime.ClientAuthInfoW
 	return auth, nil
 }
 
-func resolveToken(token, tokenPath string) (string, error) {
+func resolveToken(token, tokenPath string, command *TokenCommand) (string, error) {
 	if token != "" {
 		return token, nil
 	}
 
+	if command != nil {
+		return command.Token()
+	}
+
 	if tokenPath != "" {
 		expandedPath, err := homedir.Expand(tokenPath)
 		if err != nil {
@@ -73,8 +90,8 @@ func resolveToken(token, tokenPath string) (string, error) {
 	}
 
 	return "", NewAuthError(
-		"missing authorization token: provide either 'token' or 'token_path'",
-		"token", "token_path",
+		"missing authorization token: provide either 'token', 'token_command' or 'token_path'",
+		"token", "token_command", "token_path",
 	)
 }
 
@@ -103,27 +120,3 @@ func ToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
 
 	return diags
 }
-
-func ToSDKDiagnostics(err error) sdkdiag.Diagnostics {
-	if err == nil {
-		return nil
-	}
-
-	var authErr *AuthError
-	if errors.As(err, &authErr) {
-		var attrPath cty.Path
-		for _, attr := range authErr.Attributes {
-			attrPath = append(attrPath, cty.GetAttrStep{Name: attr})
-		}
-		return sdkdiag.Diagnostics{{
-			Severity:      sdkdiag.Error,
-			Summary:       authErr.Message,
-			AttributePath: attrPath,
-		}}
-	}
-
-	return sdkdiag.Diagnostics{{
-		Severity: sdkdiag.Error,
-		Summary:  err.Error(),
-	}}
-}
@@ -2,14 +2,18 @@ package common
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 
+	httptransport "github.com/go-openapi/runtime/client"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	k8client "github.com/syseleven/go-metakube/client"
 )
 
-func NewClient(host string) (*k8client.MetaKubeAPI, diag.Diagnostics) {
+// NewClient returns a MetaKube API client for host. Requests are sent through
+// transport, or http.DefaultTransport if it is nil.
+func NewClient(host string, transport http.RoundTripper) (*k8client.MetaKubeAPI, diag.Diagnostics) {
 	var diagnostics diag.Diagnostics
 
 	u, err := url.Parse(host)
@@ -22,9 +26,10 @@ func NewClient(host string) (*k8client.MetaKubeAPI, diag.Diagnostics) {
 		return nil, diagnostics
 	}
 
-	return k8client.NewHTTPClientWithConfig(nil, &k8client.TransportConfig{
-		Host:     u.Host,
-		BasePath: u.Path,
-		Schemes:  []string{u.Scheme},
-	}), diagnostics
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	rt := httptransport.NewWithClient(u.Host, u.Path, []string{u.Scheme}, &http.Client{Transport: transport})
+	return k8client.New(rt, nil), diagnostics
 }
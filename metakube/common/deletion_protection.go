@@ -0,0 +1,161 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+)
+
+// replacementPrivateKey marks a planned replacement in the private state.
+// Attribute plan modifiers run before the resource ModifyPlan, but the
+// replacements they require are not passed on to it.
+const replacementPrivateKey = "planned_replacement"
+
+// DeletionProtectionError is returned when a protected resource is about to
+// be deleted or replaced.
+type DeletionProtectionError struct {
+	Message string
+}
+
+func (e *DeletionProtectionError) Error() string {
+	return e.Message
+}
+
+func NewDeletionProtectionError(message string) *DeletionProtectionError {
+	return &DeletionProtectionError{Message: message}
+}
+
+// CheckDeletionProtection returns a DeletionProtectionError when the resource
+// has deletion_protection set or its labels match the provider's protect_labels.
+func (k *MetaKubeProviderMeta) CheckDeletionProtection(operation, resourceType, name string, deletionProtection bool, labels map[string]string) error {
+	if deletionProtection {
+		return NewDeletionProtectionError(fmt.Sprintf(
+			"Refusing to %s the %s %q, deletion_protection is set. Set deletion_protection = false and apply first.",
+			operation, resourceType, name))
+	}
+
+	if k == nil || len(k.ProtectLabels) == 0 {
+		return nil
+	}
+	for key, value := range k.ProtectLabels {
+		if v, ok := labels[key]; !ok || v != value {
+			return nil
+		}
+	}
+
+	var selector []string
+	for _, key := range slices.Sorted(maps.Keys(k.ProtectLabels)) {
+		selector = append(selector, key+"="+k.ProtectLabels[key])
+	}
+	return NewDeletionProtectionError(fmt.Sprintf(
+		"Refusing to %s the %s %q, its labels match the provider's protect_labels (%s). Change its labels or protect_labels and apply first.",
+		operation, resourceType, name, strings.Join(selector, ",")))
+}
+
+// RequiresReplace works like stringplanmodifier.RequiresReplace and
+// additionally records the replacement for PlannedReplacement.
+func RequiresReplace() planmodifier.String {
+	return MarkReplacementString(stringplanmodifier.RequiresReplace())
+}
+
+// MarkReplacementString wraps a plan modifier so that the replacements it
+// requires are recorded for PlannedReplacement.
+func MarkReplacementString(m planmodifier.String) planmodifier.String {
+	return markReplacementString{String: m}
+}
+
+// MarkReplacementInt64 is MarkReplacementString for int64 attributes.
+func MarkReplacementInt64(m planmodifier.Int64) planmodifier.Int64 {
+	return markReplacementInt64{Int64: m}
+}
+
+type markReplacementString struct {
+	planmodifier.String
+}
+
+func (m markReplacementString) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	m.String.PlanModifyString(ctx, req, resp)
+	if resp.RequiresReplace {
+		resp.Diagnostics.Append(markReplacement(ctx, resp.Private, req.Path)...)
+	}
+}
+
+type markReplacementInt64 struct {
+	planmodifier.Int64
+}
+
+func (m markReplacementInt64) PlanModifyInt64(ctx context.Context, req planmodifier.Int64Request, resp *planmodifier.Int64Response) {
+	m.Int64.PlanModifyInt64(ctx, req, resp)
+	if resp.RequiresReplace {
+		resp.Diagnostics.Append(markReplacement(ctx, resp.Private, req.Path)...)
+	}
+}
+
+// privateState is implemented by the private state of plan requests and responses.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, fwdiag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) fwdiag.Diagnostics
+}
+
+func markReplacement(ctx context.Context, private privateState, p path.Path) fwdiag.Diagnostics {
+	var diags fwdiag.Diagnostics
+
+	value, d := private.GetKey(ctx, replacementPrivateKey)
+	diags.Append(d...)
+	if diags.HasError() || len(value) != 0 {
+		return diags
+	}
+
+	value, err := json.Marshal(p.String())
+	if err != nil {
+		diags.AddError("Failed to record replacement", err.Error())
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, replacementPrivateKey, value)...)
+	return diags
+}
+
+// PlannedReplacement returns the first attribute requiring the replacement of
+// the resource, as recorded by RequiresReplace, and removes the record from
+// the planned private state.
+func PlannedReplacement(ctx context.Context, planned privateState) (string, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
+
+	value, d := planned.GetKey(ctx, replacementPrivateKey)
+	diags.Append(d...)
+	if diags.HasError() || len(value) == 0 {
+		return "", diags
+	}
+	diags.Append(planned.SetKey(ctx, replacementPrivateKey, nil)...)
+
+	var attribute string
+	if err := json.Unmarshal(value, &attribute); err != nil {
+		diags.AddError("Failed to read planned replacement", err.Error())
+	}
+	return attribute, diags
+}
+
+func DeletionProtectionToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var diags fwdiag.Diagnostics
+	var protectionErr *DeletionProtectionError
+	if errors.As(err, &protectionErr) {
+		diags.AddAttributeError(path.Root("deletion_protection"), "Deletion Protection", protectionErr.Message)
+	} else {
+		diags.AddError("Deletion Protection", err.Error())
+	}
+
+	return diags
+}
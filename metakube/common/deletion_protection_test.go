@@ -0,0 +1,140 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// testPrivateState is a map backed privateState.
+type testPrivateState map[string][]byte
+
+func (s testPrivateState) GetKey(_ context.Context, key string) ([]byte, fwdiag.Diagnostics) {
+	return s[key], nil
+}
+
+func (s testPrivateState) SetKey(_ context.Context, key string, value []byte) fwdiag.Diagnostics {
+	if len(value) == 0 {
+		delete(s, key)
+	} else {
+		s[key] = value
+	}
+	return nil
+}
+
+func TestCheckDeletionProtection(t *testing.T) {
+	tests := []struct {
+		name               string
+		meta               *MetaKubeProviderMeta
+		deletionProtection bool
+		labels             map[string]string
+		wantErr            string
+	}{
+		{
+			name: "not configured",
+			meta: nil,
+		},
+		{
+			name:               "deletion_protection set",
+			meta:               nil,
+			deletionProtection: true,
+			wantErr:            `Refusing to delete the cluster "c", deletion_protection is set. Set deletion_protection = false and apply first.`,
+		},
+		{
+			name:   "no protect_labels",
+			meta:   &MetaKubeProviderMeta{},
+			labels: map[string]string{"env": "prod"},
+		},
+		{
+			name:    "all protect_labels match",
+			meta:    &MetaKubeProviderMeta{ProtectLabels: map[string]string{"tier": "critical", "env": "prod"}},
+			labels:  map[string]string{"env": "prod", "tier": "critical", "team": "a"},
+			wantErr: `Refusing to delete the cluster "c", its labels match the provider's protect_labels (env=prod,tier=critical). Change its labels or protect_labels and apply first.`,
+		},
+		{
+			name:   "some protect_labels match",
+			meta:   &MetaKubeProviderMeta{ProtectLabels: map[string]string{"tier": "critical", "env": "prod"}},
+			labels: map[string]string{"env": "prod"},
+		},
+		{
+			name:   "protect_labels value differs",
+			meta:   &MetaKubeProviderMeta{ProtectLabels: map[string]string{"env": "prod"}},
+			labels: map[string]string{"env": "staging"},
+		},
+		{
+			name: "no labels",
+			meta: &MetaKubeProviderMeta{ProtectLabels: map[string]string{"env": "prod"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.meta.CheckDeletionProtection("delete", "cluster", "c", tt.deletionProtection, tt.labels)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var protectionErr *DeletionProtectionError
+			if !errors.As(err, &protectionErr) {
+				t.Fatalf("expected a DeletionProtectionError, got %v", err)
+			}
+			if protectionErr.Message != tt.wantErr {
+				t.Errorf("expected %q, got %q", tt.wantErr, protectionErr.Message)
+			}
+			if diags := DeletionProtectionToFrameworkDiagnostics(err); !diags.HasError() {
+				t.Errorf("expected error diagnostics, got %v", diags)
+			}
+		})
+	}
+}
+
+func TestPlannedReplacement(t *testing.T) {
+	tests := []struct {
+		name   string
+		marked []path.Path
+		want   string
+	}{
+		{
+			name: "no replacement",
+		},
+		{
+			name:   "replacement",
+			marked: []path.Path{path.Root("project_id")},
+			want:   "project_id",
+		},
+		{
+			name:   "first replacement is kept",
+			marked: []path.Path{path.Root("spec").AtListIndex(0).AtName("cloud"), path.Root("project_id")},
+			want:   "spec[0].cloud",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			private := testPrivateState{}
+			for _, p := range tt.marked {
+				if diags := markReplacement(ctx, private, p); diags.HasError() {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+			}
+
+			got, diags := PlannedReplacement(ctx, private)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if _, ok := private[replacementPrivateKey]; ok {
+				t.Error("expected the planned replacement to be removed from the private state")
+			}
+		})
+	}
+}
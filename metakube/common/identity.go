@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ProjectResourceIdentityModel is the resource identity of objects owned by a
+// project, such as clusters and SSH keys.
+type ProjectResourceIdentityModel struct {
+	ProjectID types.String `tfsdk:"project_id"`
+	ID        types.String `tfsdk:"id"`
+}
+
+// ClusterResourceIdentityModel is the resource identity of objects inside a
+// cluster, such as node deployments.
+type ClusterResourceIdentityModel struct {
+	ProjectID types.String `tfsdk:"project_id"`
+	ClusterID types.String `tfsdk:"cluster_id"`
+	ID        types.String `tfsdk:"id"`
+}
+
+// ProjectResourceIdentitySchema returns the identity schema matching
+// ProjectResourceIdentityModel. The project is optional on import and looked
+// up by id when omitted.
+func ProjectResourceIdentitySchema(idDescription string) identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"project_id": identityschema.StringAttribute{
+				OptionalForImport: true,
+				Description:       "Project the resource belongs to",
+			},
+			"id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       idDescription,
+			},
+		},
+	}
+}
+
+// ClusterResourceIdentitySchema returns the identity schema matching
+// ClusterResourceIdentityModel.
+func ClusterResourceIdentitySchema(idDescription string) identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"project_id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "Project the cluster belongs to",
+			},
+			"cluster_id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "Cluster the resource belongs to",
+			},
+			"id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       idDescription,
+			},
+		},
+	}
+}
+
+// ImportClusterResourceIdentity returns the identity of a cluster resource
+// being imported, either from the import identity or from the legacy import ID
+// 'project_id:cluster_id:<idName>'.
+func ImportClusterResourceIdentity(ctx context.Context, req resource.ImportStateRequest, idName string) (ClusterResourceIdentityModel, diag.Diagnostics) {
+	var identity ClusterResourceIdentityModel
+	var diags diag.Diagnostics
+
+	if req.ID == "" {
+		diags.Append(req.Identity.Get(ctx, &identity)...)
+		return identity, diags
+	}
+
+	parts := strings.Split(req.ID, ":")
+	if len(parts) != 3 {
+		diags.AddError(
+			"Invalid import ID",
+			fmt.Sprintf("please provide resource identifier in format 'project_id:cluster_id:%s'", idName),
+		)
+		return identity, diags
+	}
+
+	identity.ProjectID = types.StringValue(parts[0])
+	identity.ClusterID = types.StringValue(parts[1])
+	identity.ID = types.StringValue(parts[2])
+	return identity, diags
+}
@@ -0,0 +1,173 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/syseleven/go-metakube/client/project"
+	"gopkg.in/yaml.v3"
+)
+
+// Kubeconfig variants served by the MetaKube API.
+const (
+	KubeconfigAdmin     = "admin"
+	KubeconfigOIDC      = "oidc"
+	KubeconfigKubeLogin = "kubelogin"
+)
+
+// KubeconfigTypes lists the kubeconfig variants accepted by MetakubeGetKubeconfig.
+var KubeconfigTypes = []string{KubeconfigAdmin, KubeconfigOIDC, KubeconfigKubeLogin}
+
+// MetakubeGetKubeconfig returns the kubeconfig of the given variant for a cluster.
+func MetakubeGetKubeconfig(ctx context.Context, k *MetaKubeProviderMeta, projectID, clusterID, kubeconfigType string) (string, error) {
+	switch kubeconfigType {
+	case KubeconfigAdmin:
+		p := project.NewGetClusterKubeconfigV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID)
+		ret, err := k.Client.Project.GetClusterKubeconfigV2(p, k.Auth)
+		if err != nil {
+			return "", fmt.Errorf("failed to get kube_config: %s", StringifyResponseError(err))
+		}
+		return string(ret.Payload), nil
+	case KubeconfigOIDC:
+		p := project.NewGetOidcClusterKubeconfigV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID)
+		ret, err := k.Client.Project.GetOidcClusterKubeconfigV2(p, k.Auth)
+		if err != nil {
+			return "", fmt.Errorf("failed to get oidc_kube_config: %s", StringifyResponseError(err))
+		}
+		return string(ret.Payload), nil
+	case KubeconfigKubeLogin:
+		p := project.NewGetKubeLoginClusterKubeconfigV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID)
+		ret, err := k.Client.Project.GetKubeLoginClusterKubeconfigV2(p, k.Auth)
+		if err != nil {
+			return "", fmt.Errorf("failed to get kube_login_kube_config: %s", StringifyResponseError(err))
+		}
+		return string(ret.Payload), nil
+	}
+	return "", fmt.Errorf("unknown kubeconfig type %q", kubeconfigType)
+}
+
+// Kubeconfig is a kubeconfig file as used by the provider functions. Keys that
+// are not modelled are kept in Extra, so that they survive a round trip.
+type Kubeconfig struct {
+	APIVersion     string                    `yaml:"apiVersion,omitempty"`
+	Kind           string                    `yaml:"kind,omitempty"`
+	CurrentContext string                    `yaml:"current-context"`
+	Clusters       []*KubeconfigNamedCluster `yaml:"clusters"`
+	Contexts       []*KubeconfigNamedContext `yaml:"contexts"`
+	Users          []*KubeconfigNamedUser    `yaml:"users"`
+	Extra          map[string]any            `yaml:",inline"`
+}
+
+type KubeconfigNamedCluster struct {
+	Name    string            `yaml:"name"`
+	Cluster KubeconfigCluster `yaml:"cluster"`
+}
+
+type KubeconfigCluster struct {
+	Server                   string         `yaml:"server"`
+	CertificateAuthorityData string         `yaml:"certificate-authority-data,omitempty"`
+	Extra                    map[string]any `yaml:",inline"`
+}
+
+type KubeconfigNamedContext struct {
+	Name    string            `yaml:"name"`
+	Context KubeconfigContext `yaml:"context"`
+}
+
+type KubeconfigContext struct {
+	Cluster   string         `yaml:"cluster"`
+	User      string         `yaml:"user"`
+	Namespace string         `yaml:"namespace,omitempty"`
+	Extra     map[string]any `yaml:",inline"`
+}
+
+type KubeconfigNamedUser struct {
+	Name string         `yaml:"name"`
+	User KubeconfigUser `yaml:"user"`
+}
+
+type KubeconfigUser struct {
+	ClientCertificateData string                  `yaml:"client-certificate-data,omitempty"`
+	ClientKeyData         string                  `yaml:"client-key-data,omitempty"`
+	Token                 string                  `yaml:"token,omitempty"`
+	Exec                  *KubeconfigExec         `yaml:"exec,omitempty"`
+	AuthProvider          *KubeconfigAuthProvider `yaml:"auth-provider,omitempty"`
+	Extra                 map[string]any          `yaml:",inline"`
+}
+
+type KubeconfigExec struct {
+	APIVersion string              `yaml:"apiVersion"`
+	Command    string              `yaml:"command"`
+	Args       []string            `yaml:"args,omitempty"`
+	Env        []KubeconfigExecEnv `yaml:"env,omitempty"`
+	Extra      map[string]any      `yaml:",inline"`
+}
+
+type KubeconfigExecEnv struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type KubeconfigAuthProvider struct {
+	Name   string            `yaml:"name"`
+	Config map[string]string `yaml:"config,omitempty"`
+}
+
+// ParseKubeconfig parses a kubeconfig as returned by the MetaKube API.
+func ParseKubeconfig(s string) (*Kubeconfig, error) {
+	var k Kubeconfig
+	if err := yaml.Unmarshal([]byte(s), &k); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %v", err)
+	}
+	if len(k.Contexts) == 0 {
+		return nil, errors.New("invalid kubeconfig: no contexts")
+	}
+	return &k, nil
+}
+
+// String returns the kubeconfig as YAML.
+func (k *Kubeconfig) String() (string, error) {
+	b, err := yaml.Marshal(k)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// CurrentNamedContext returns the current context, or the only context when
+// current-context is not set.
+func (k *Kubeconfig) CurrentNamedContext() (*KubeconfigNamedContext, error) {
+	if k.CurrentContext == "" {
+		if len(k.Contexts) == 1 {
+			return k.Contexts[0], nil
+		}
+		return nil, errors.New("kubeconfig has several contexts but no current-context")
+	}
+	for _, c := range k.Contexts {
+		if c.Name == k.CurrentContext {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("current-context %q not found in kubeconfig", k.CurrentContext)
+}
+
+// NamedCluster returns the cluster with the given name, nil if there is none.
+func (k *Kubeconfig) NamedCluster(name string) *KubeconfigNamedCluster {
+	for _, c := range k.Clusters {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// NamedUser returns the user with the given name, nil if there is none.
+func (k *Kubeconfig) NamedUser(name string) *KubeconfigNamedUser {
+	for _, u := range k.Users {
+		if u.Name == name {
+			return u
+		}
+	}
+	return nil
+}
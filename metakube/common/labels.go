@@ -0,0 +1,120 @@
+package common
+
+import "strings"
+
+// MergeDefaultLabels returns labels with the default labels added. Labels set
+// on the resource take precedence over defaults with the same key.
+func MergeDefaultLabels(defaults, labels map[string]string) map[string]string {
+	result := make(map[string]string, len(defaults)+len(labels))
+	for k, v := range defaults {
+		result[k] = v
+	}
+	for k, v := range labels {
+		result[k] = v
+	}
+	return result
+}
+
+// StripDefaultLabels returns labels without the ones that were only added from
+// the defaults, i.e. carry the default value and are not in configured. Labels
+// the resource sets itself are kept even when they equal the default, so
+// neither case shows a diff.
+func StripDefaultLabels(defaults, labels, configured map[string]string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if d, ok := defaults[k]; ok && d == v {
+			if _, ok := configured[k]; !ok {
+				continue
+			}
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// DefaultIgnoreLabelPrefixes are the prefixes of labels managed by MetaKube or
+// Kubernetes, used unless the provider sets ignore_label_prefixes.
+var DefaultIgnoreLabelPrefixes = []string{
+	"system/",
+	"system-",
+	"kubernetes.io/",
+	"k8s.io/",
+	"metakube.syseleven.de/",
+}
+
+// LabelFilter tells system labels, which are managed by MetaKube or Kubernetes
+// and not by the configuration, from user labels.
+type LabelFilter struct {
+	prefixes []string
+	keys     map[string]bool
+	// configured holds the keys set in the configuration, never system labels.
+	configured map[string]string
+}
+
+// NewLabelFilter returns a filter treating labels as system labels when their
+// key equals one of keys or starts with one of prefixes. A prefix ending with
+// "/" also matches the subdomains of its DNS prefix, e.g. "kubernetes.io/"
+// matches "node.kubernetes.io/instance-type". A nil prefixes uses DefaultIgnoreLabelPrefixes.
+func NewLabelFilter(prefixes, keys []string) *LabelFilter {
+	if prefixes == nil {
+		prefixes = DefaultIgnoreLabelPrefixes
+	}
+	f := &LabelFilter{prefixes: prefixes, keys: make(map[string]bool, len(keys))}
+	for _, k := range keys {
+		f.keys[k] = true
+	}
+	return f
+}
+
+// IsSystemLabel reports whether key is a system label. A nil filter uses the defaults.
+func (f *LabelFilter) IsSystemLabel(key string) bool {
+	if f == nil {
+		f = defaultLabelFilter
+	}
+	if _, ok := f.configured[key]; ok {
+		return false
+	}
+	if f.keys[key] {
+		return true
+	}
+
+	for _, p := range f.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+		if domain, ok := strings.CutSuffix(p, "/"); ok {
+			if keyPrefix, _, ok := strings.Cut(key, "/"); ok && strings.HasSuffix(keyPrefix, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithConfigured returns a copy of the filter that treats the keys of
+// configured as user labels, so that labels set in the configuration are kept
+// even when they match an ignored prefix, e.g. "node-role.kubernetes.io/worker".
+func (f *LabelFilter) WithConfigured(configured map[string]string) *LabelFilter {
+	if f == nil {
+		f = defaultLabelFilter
+	}
+	c := *f
+	c.configured = configured
+	return &c
+}
+
+// SplitLabels returns the user labels and the system labels of labels.
+func (f *LabelFilter) SplitLabels(labels map[string]string) (user, system map[string]string) {
+	user = make(map[string]string, len(labels))
+	system = make(map[string]string)
+	for k, v := range labels {
+		if f.IsSystemLabel(k) {
+			system[k] = v
+		} else {
+			user[k] = v
+		}
+	}
+	return user, system
+}
+
+var defaultLabelFilter = NewLabelFilter(nil, nil)
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// RequestLimiter throttles the requests of all resources and data sources of
+// one provider instance. It combines a token bucket, limiting the request rate,
+// with a semaphore, limiting the number of requests in flight.
+type RequestLimiter struct {
+	rate     *rate.Limiter
+	inFlight chan struct{}
+}
+
+// NewRequestLimiter returns a limiter allowing requestsPerSecond requests per
+// second and maxConcurrent requests at a time. Zero disables the respective limit.
+func NewRequestLimiter(requestsPerSecond float64, maxConcurrent int) *RequestLimiter {
+	l := &RequestLimiter{}
+	if requestsPerSecond > 0 {
+		burst := int(math.Max(1, math.Ceil(requestsPerSecond)))
+		l.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+	if maxConcurrent > 0 {
+		l.inFlight = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// Acquire blocks until a request may be sent. The returned func must be called
+// once the request has finished.
+func (l *RequestLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if l.inFlight != nil {
+			<-l.inFlight
+		}
+	}
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *RequestLimiter
+}
+
+// NewLimitedTransport wraps base so that every request, including retries,
+// goes through limiter.
+func NewLimitedTransport(base http.RoundTripper, limiter *RequestLimiter) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitedTransport{base: base, limiter: limiter}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.Acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	// The slot is held until the response headers arrived, the body is read
+	// by the client afterwards and doesn't count against the limit.
+	defer release()
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitedTransportConcurrency(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		requests      int
+		wantMax       int32
+	}{
+		{name: "capped", maxConcurrent: 2, requests: 8, wantMax: 2},
+		{name: "single", maxConcurrent: 1, requests: 4, wantMax: 1},
+		{name: "unlimited", maxConcurrent: 0, requests: 4, wantMax: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, maxInFlight int32
+			var ready sync.WaitGroup
+			ready.Add(tt.requests)
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				n := atomic.AddInt32(&inFlight, 1)
+				for {
+					m := atomic.LoadInt32(&maxInFlight)
+					if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&inFlight, -1)
+				return testResponse(http.StatusOK, nil), nil
+			})
+			transport := NewLimitedTransport(base, NewRequestLimiter(0, tt.maxConcurrent))
+
+			var wg sync.WaitGroup
+			for range tt.requests {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req, _ := http.NewRequest(http.MethodGet, "https://metakube.example.com", nil)
+					ready.Done()
+					ready.Wait()
+					if _, err := transport.RoundTrip(req); err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := atomic.LoadInt32(&maxInFlight); got > tt.wantMax {
+				t.Errorf("expected at most %d requests in flight, got %d", tt.wantMax, got)
+			}
+		})
+	}
+}
+
+func TestRequestLimiterRate(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestsPerSecond float64
+		requests          int
+		wantMin           time.Duration
+		wantMax           time.Duration
+	}{
+		{name: "burst is not delayed", requestsPerSecond: 50, requests: 50, wantMax: 100 * time.Millisecond},
+		{name: "requests beyond the burst are delayed", requestsPerSecond: 50, requests: 60, wantMin: 150 * time.Millisecond},
+		{name: "unlimited", requestsPerSecond: 0, requests: 100, wantMax: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRequestLimiter(tt.requestsPerSecond, 0)
+
+			start := time.Now()
+			for range tt.requests {
+				release, err := limiter.Acquire(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				release()
+			}
+			elapsed := time.Since(start)
+
+			if elapsed < tt.wantMin || (tt.wantMax > 0 && elapsed > tt.wantMax) {
+				t.Errorf("expected %d requests to take between %v and %v, took %v", tt.requests, tt.wantMin, tt.wantMax, elapsed)
+			}
+		})
+	}
+}
+
+func TestRequestLimiterCanceled(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestsPerSecond float64
+		maxConcurrent     int
+	}{
+		{name: "waiting for a slot", maxConcurrent: 1},
+		{name: "waiting for the rate", requestsPerSecond: 0.001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRequestLimiter(tt.requestsPerSecond, tt.maxConcurrent)
+			if _, err := limiter.Acquire(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := limiter.Acquire(ctx); err == nil {
+				t.Error("expected an error once the context is done")
+			}
+		})
+	}
+}
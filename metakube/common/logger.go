@@ -1,14 +1,15 @@
 package common
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"strings"
 
-	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-hclog"
 	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
-	sdkdiag "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -29,49 +30,187 @@ func NewLoggerError(message string, attributes ...string) *LoggerError {
 	}
 }
 
-func NewLogger(config MetakubeProviderConfig, fd *os.File) (*zap.SugaredLogger, error) {
+// Log subsystems, the level of each can be set with TF_LOG_PROVIDER_METAKUBE_<SUBSYSTEM>,
+// e.g. TF_LOG_PROVIDER_METAKUBE_NODE_DEPLOYMENT=trace.
+const (
+	LogSubsystemCluster        = "cluster"
+	LogSubsystemNodeDeployment = "node_deployment"
+	LogSubsystemRBAC           = "rbac"
+	LogSubsystemMaintenance    = "maintenance"
+	LogSubsystemSSHKey         = "sshkey"
+)
+
+type logSubsystemKey struct{}
+
+// Logger writes provider logs through tflog, so TF_LOG and TF_LOG_PROVIDER
+// apply and Terraform's log file captures them. With log_path set, every
+// entry is additionally written as JSON into that file.
+type Logger struct {
+	// level is the configured log_level, hclog.NoLevel leaves filtering to TF_LOG.
+	level hclog.Level
+	sink  *zap.Logger
+}
+
+// NewLogger returns the provider logger. With trace_http enabled it also
+// returns a logger for HTTP traces, which writes only into the JSON sink at
+// log_path, otherwise the trace logger is nil.
+func NewLogger(config MetakubeProviderConfig) (*Logger, *zap.Logger, error) {
 	var (
-		ec    zapcore.EncoderConfig
-		cores []zapcore.Core
-		level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+		l     = &Logger{level: hclog.NoLevel}
+		trace *zap.Logger
 	)
 
-	logDev := config.Development.ValueBool()
-	logDebug := config.Debug.ValueBool()
+	logLevel := config.LogLevel.ValueString()
 	logPath := config.LogPath.ValueString()
+	traceHTTP := config.TraceHTTP.ValueBool()
 
-	if logDev || logDebug {
-		level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	// development and debug are deprecated in favor of log_level.
+	if logLevel == "" && (config.Development.ValueBool() || config.Debug.ValueBool()) {
+		logLevel = "debug"
 	}
-
-	if logDev {
-		ec = zap.NewDevelopmentEncoderConfig()
-		ec.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	} else {
-		ec = zap.NewProductionEncoderConfig()
-		ec.EncodeLevel = func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
-			enc.AppendString("[" + level.CapitalString() + "]")
+	if logLevel != "" {
+		l.level = hclog.LevelFromString(logLevel)
+		if l.level == hclog.NoLevel || l.level == hclog.Off {
+			return nil, nil, NewLoggerError(
+				fmt.Sprintf("invalid log level %q, expected one of trace, debug, info, warn, error", logLevel),
+				"log_level",
+			)
 		}
 	}
-	ec.EncodeTime = zapcore.ISO8601TimeEncoder
-	ec.EncodeDuration = zapcore.StringDurationEncoder
+
+	if traceHTTP && logPath == "" {
+		return nil, nil, NewLoggerError("trace_http requires log_path to be set", "trace_http", "log_path")
+	}
 
 	if logPath != "" {
-		jsonEC := ec
-		jsonEC.EncodeLevel = zapcore.LowercaseLevelEncoder
+		ec := zap.NewProductionEncoderConfig()
+		ec.EncodeLevel = zapcore.LowercaseLevelEncoder
+		ec.EncodeTime = zapcore.ISO8601TimeEncoder
+		ec.EncodeDuration = zapcore.StringDurationEncoder
+
 		sink, _, err := zap.Open(logPath)
 		if err != nil {
-			return nil, NewLoggerError(
+			return nil, nil, NewLoggerError(
 				fmt.Sprintf("cannot access log location: %v", err),
 				"log_path",
 			)
 		}
-		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(jsonEC), sink, level))
+
+		sinkLevel := zapcore.InfoLevel
+		if l.level != hclog.NoLevel {
+			sinkLevel = zapLevel(l.level)
+		}
+		l.sink = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(ec), sink, sinkLevel))
+
+		if traceHTTP {
+			trace = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(ec), sink, zapcore.DebugLevel))
+		}
 	}
 
-	cores = append(cores, zapcore.NewCore(zapcore.NewConsoleEncoder(ec), zapcore.AddSync(fd), level))
-	core := zapcore.NewTee(cores...)
-	return zap.New(core).Sugar(), nil
+	return l, trace, nil
+}
+
+// NewNopLogger returns a Logger that only logs through tflog, e.g. for tests and sweepers.
+func NewNopLogger() *Logger {
+	return &Logger{level: hclog.NoLevel}
+}
+
+// WithSubsystem returns a context whose log entries are attributed to subsystem.
+func (l *Logger) WithSubsystem(ctx context.Context, subsystem string) context.Context {
+	if l.level != hclog.NoLevel {
+		ctx = tflog.NewSubsystem(ctx, subsystem,
+			tflog.WithRootFields(),
+			tflog.WithAdditionalLocationOffset(1),
+			tflog.WithLevel(l.level),
+		)
+	} else {
+		ctx = tflog.NewSubsystem(ctx, subsystem,
+			tflog.WithRootFields(),
+			tflog.WithAdditionalLocationOffset(1),
+			tflog.WithLevelFromEnv("TF_LOG_PROVIDER_METAKUBE", strings.ToUpper(subsystem)),
+		)
+	}
+	return context.WithValue(ctx, logSubsystemKey{}, subsystem)
+}
+
+func (l *Logger) Tracef(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, hclog.Trace, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, hclog.Debug, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Infof(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, hclog.Info, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Warnf(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, hclog.Warn, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	l.log(ctx, hclog.Error, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) log(ctx context.Context, level hclog.Level, msg string) {
+	subsystem, _ := ctx.Value(logSubsystemKey{}).(string)
+
+	if l.sink != nil {
+		if ce := l.sink.Check(zapLevel(level), msg); ce != nil {
+			var fields []zap.Field
+			if subsystem != "" {
+				fields = append(fields, zap.String("subsystem", subsystem))
+			}
+			ce.Write(fields...)
+		}
+	}
+
+	// Without a subsystem the entry goes to the root logger, which TF_LOG_PROVIDER filters.
+	if subsystem == "" {
+		if l.level != hclog.NoLevel && level < l.level {
+			return
+		}
+		switch level {
+		case hclog.Trace:
+			tflog.Trace(ctx, msg)
+		case hclog.Debug:
+			tflog.Debug(ctx, msg)
+		case hclog.Info:
+			tflog.Info(ctx, msg)
+		case hclog.Warn:
+			tflog.Warn(ctx, msg)
+		default:
+			tflog.Error(ctx, msg)
+		}
+		return
+	}
+
+	switch level {
+	case hclog.Trace:
+		tflog.SubsystemTrace(ctx, subsystem, msg)
+	case hclog.Debug:
+		tflog.SubsystemDebug(ctx, subsystem, msg)
+	case hclog.Info:
+		tflog.SubsystemInfo(ctx, subsystem, msg)
+	case hclog.Warn:
+		tflog.SubsystemWarn(ctx, subsystem, msg)
+	default:
+		tflog.SubsystemError(ctx, subsystem, msg)
+	}
+}
+
+func zapLevel(level hclog.Level) zapcore.Level {
+	switch level {
+	case hclog.Trace, hclog.Debug:
+		return zapcore.DebugLevel
+	case hclog.Info:
+		return zapcore.InfoLevel
+	case hclog.Warn:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
 }
 
 func LoggerToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
@@ -99,27 +238,3 @@ func LoggerToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
 
 	return diags
 }
-
-func LoggerToSDKDiagnostics(err error) sdkdiag.Diagnostics {
-	if err == nil {
-		return nil
-	}
-
-	var logErr *LoggerError
-	if errors.As(err, &logErr) {
-		var attrPath cty.Path
-		for _, attr := range logErr.Attributes {
-			attrPath = append(attrPath, cty.GetAttrStep{Name: attr})
-		}
-		return sdkdiag.Diagnostics{{
-			Severity:      sdkdiag.Error,
-			Summary:       logErr.Message,
-			AttributePath: attrPath,
-		}}
-	}
-
-	return sdkdiag.Diagnostics{{
-		Severity: sdkdiag.Error,
-		Summary:  err.Error(),
-	}}
-}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestNewLogger(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name           string
+		logLevel       string
+		logPath        string
+		traceHTTP      bool
+		development    bool
+		wantAttributes []string
+		wantLogged     []string
+		wantNotLogged  []string
+		wantTrace      bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:     "log level",
+			logLevel: "WARN",
+		},
+		{
+			name:           "invalid log level",
+			logLevel:       "verbose",
+			wantAttributes: []string{"log_level"},
+		},
+		{
+			name:           "log level off",
+			logLevel:       "off",
+			wantAttributes: []string{"log_level"},
+		},
+		{
+			name:          "log path",
+			logPath:       "info.log",
+			wantLogged:    []string{`"msg":"info message"`, `"subsystem":"cluster"`, `"msg":"warn message"`},
+			wantNotLogged: []string{"debug message"},
+		},
+		{
+			name:          "log path with log level",
+			logLevel:      "warn",
+			logPath:       "warn.log",
+			wantLogged:    []string{`"msg":"warn message"`},
+			wantNotLogged: []string{"debug message", "info message"},
+		},
+		{
+			name:        "log path with deprecated development",
+			development: true,
+			logPath:     "development.log",
+			wantLogged:  []string{`"msg":"debug message"`, `"msg":"info message"`},
+		},
+		{
+			name:           "log path in missing directory",
+			logPath:        "missing/provider.log",
+			wantAttributes: []string{"log_path"},
+		},
+		{
+			name:       "trace_http with log path",
+			logPath:    "trace.log",
+			traceHTTP:  true,
+			wantLogged: []string{`"msg":"info message"`},
+			wantTrace:  true,
+		},
+		{
+			name:           "trace_http without log path",
+			traceHTTP:      true,
+			wantAttributes: []string{"trace_http", "log_path"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := MetakubeProviderConfig{
+				LogLevel:    types.StringValue(tt.logLevel),
+				TraceHTTP:   types.BoolValue(tt.traceHTTP),
+				Development: types.BoolValue(tt.development),
+			}
+			logPath := ""
+			if tt.logPath != "" {
+				logPath = filepath.Join(dir, tt.logPath)
+				config.LogPath = types.StringValue(logPath)
+			}
+
+			logger, trace, err := NewLogger(config)
+			if tt.wantAttributes != nil {
+				var loggerErr *LoggerError
+				if !errors.As(err, &loggerErr) {
+					t.Fatalf("expected a LoggerError, got %v", err)
+				}
+				var got []string
+				for _, d := range LoggerToFrameworkDiagnostics(err) {
+					withPath, ok := d.(fwdiag.DiagnosticWithPath)
+					if !ok {
+						t.Fatalf("expected an attribute diagnostic, got %v", d)
+					}
+					got = append(got, withPath.Path().String())
+				}
+				if diff := cmp.Diff(tt.wantAttributes, got); diff != "" {
+					t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (trace != nil) != tt.wantTrace {
+				t.Errorf("expected trace logger %v, got %v", tt.wantTrace, trace != nil)
+			}
+
+			ctx := logger.WithSubsystem(context.Background(), LogSubsystemCluster)
+			logger.Debugf(ctx, "debug message")
+			logger.Infof(ctx, "info message")
+			logger.Warnf(ctx, "warn message")
+			if logPath == "" {
+				return
+			}
+
+			raw, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantLogged {
+				if !strings.Contains(string(raw), want) {
+					t.Errorf("expected %s to be logged, got\n%s", want, raw)
+				}
+			}
+			for _, notWant := range tt.wantNotLogged {
+				if strings.Contains(string(raw), notWant) {
+					t.Errorf("expected %q not to be logged, got\n%s", notWant, raw)
+				}
+			}
+		})
+	}
+}
@@ -2,22 +2,97 @@ package common
 
 import (
 	"github.com/go-openapi/runtime"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	k8client "github.com/syseleven/go-metakube/client"
-	"go.uber.org/zap"
 )
 
 type MetaKubeProviderMeta struct {
 	Client *k8client.MetaKubeAPI
 	Auth   runtime.ClientAuthInfoWriter
-	Log    *zap.SugaredLogger
+	Log    *Logger
+
+	// Limiter throttles the requests of all resources and data sources.
+	Limiter *RequestLimiter
+
+	// DefaultProjectID is the project of the selected profile, used when a
+	// resource does not set project_id.
+	DefaultProjectID string
+
+	// DefaultLabels are merged into the labels of clusters and node deployments
+	// and into the instance tags of node deployments.
+	DefaultLabels map[string]string
+
+	// ProtectLabels protects clusters and node deployments whose labels contain
+	// all of them from deletion and replacement.
+	ProtectLabels map[string]string
+
+	// LabelFilter tells the system labels, which are not managed by the
+	// configuration, from user labels.
+	LabelFilter *LabelFilter
+
+	// SkipRemoteValidation leaves the checks against the MetaKube API that
+	// run during plan to apply. Currently that is only the version upgrade
+	// check of clusters.
+	SkipRemoteValidation bool
+
+	// ReadOnly refuses every Create, Update and Delete, and every API request
+	// other than GET and HEAD.
+	ReadOnly bool
+
+	// SkipKubeconfigs keeps the kubeconfigs of clusters out of the state,
+	// they are available through the metakube_cluster_kubeconfig ephemeral
+	// resource instead.
+	SkipKubeconfigs bool
+}
+
+// UnconfiguredProviderDiagnostics is returned by operations Terraform does not
+// defer when the provider configuration is unknown, such as listing resources.
+func UnconfiguredProviderDiagnostics() fwdiag.Diagnostics {
+	var diags fwdiag.Diagnostics
+	diags.AddError(
+		"Unconfigured MetaKube provider",
+		"The provider configuration depends on values that are not known yet. Apply the resources it depends on first.",
+	)
+	return diags
 }
 
 type MetakubeProviderConfig struct {
-	Host        types.String `tfsdk:"host"`
-	Token       types.String `tfsdk:"token"`
-	TokenPath   types.String `tfsdk:"token_path"`
-	Development types.Bool   `tfsdk:"development"`
-	Debug       types.Bool   `tfsdk:"debug"`
-	LogPath     types.String `tfsdk:"log_path"`
+	Host         types.String `tfsdk:"host"`
+	Token        types.String `tfsdk:"token"`
+	TokenPath    types.String `tfsdk:"token_path"`
+	TokenCommand types.List   `tfsdk:"token_command"`
+	Profile      types.String `tfsdk:"profile"`
+	Development  types.Bool   `tfsdk:"development"`
+	Debug        types.Bool   `tfsdk:"debug"`
+	LogLevel     types.String `tfsdk:"log_level"`
+	LogPath      types.String `tfsdk:"log_path"`
+	TraceHTTP    types.Bool   `tfsdk:"trace_http"`
+
+	CAFile               types.String `tfsdk:"ca_file"`
+	CAPEM                types.String `tfsdk:"ca_pem"`
+	ClientCert           types.String `tfsdk:"client_cert"`
+	ClientKey            types.String `tfsdk:"client_key"`
+	ProxyURL             types.String `tfsdk:"proxy_url"`
+	InsecureSkipVerify   types.Bool   `tfsdk:"insecure_skip_verify"`
+	SkipRemoteValidation types.Bool   `tfsdk:"skip_remote_validation"`
+	ReadOnly             types.Bool   `tfsdk:"read_only"`
+	SkipKubeconfigs      types.Bool   `tfsdk:"skip_kubeconfigs"`
+	RetryMaxAttempts     types.Int64  `tfsdk:"retry_max_attempts"`
+
+	MaxRequestsPerSecond  types.Float64 `tfsdk:"max_requests_per_second"`
+	MaxConcurrentRequests types.Int64   `tfsdk:"max_concurrent_requests"`
+
+	OIDCIssuerURL    types.String `tfsdk:"oidc_issuer_url"`
+	OIDCClientID     types.String `tfsdk:"oidc_client_id"`
+	OIDCClientSecret types.String `tfsdk:"oidc_client_secret"`
+	OIDCRefreshToken types.String `tfsdk:"oidc_refresh_token"`
+
+	TokenExpiryWarning types.String `tfsdk:"token_expiry_warning"`
+	SkipPreflight      types.Bool   `tfsdk:"skip_preflight"`
+
+	DefaultLabels       types.Map  `tfsdk:"default_labels"`
+	IgnoreLabelPrefixes types.List `tfsdk:"ignore_label_prefixes"`
+	IgnoreLabelKeys     types.List `tfsdk:"ignore_label_keys"`
+	ProtectLabels       types.Map  `tfsdk:"protect_labels"`
 }
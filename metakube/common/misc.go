@@ -6,11 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/syseleven/go-metakube/client/project"
 	"github.com/syseleven/go-metakube/models"
 )
@@ -132,45 +130,6 @@ func IntToInt32Ptr(v int) *int32 {
 	return &vv
 }
 
-func ImportResourceWithProjectAndClusterID(identifierName string) schema.StateContextFunc {
-	return func(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-		parts := strings.Split(d.Id(), ":")
-		if len(parts) != 3 {
-			return nil, fmt.Errorf("please provide resource identifier in format 'project_id:cluster_id:%s'", identifierName)
-		}
-		d.Set("project_id", parts[0])
-		d.Set("cluster_id", parts[1])
-		d.SetId(parts[2])
-		return []*schema.ResourceData{d}, nil
-	}
-}
-
-func ImportResourceWithOptionalProject(identifierName string) schema.StateContextFunc {
-	return func(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-		parts := strings.Split(d.Id(), ":")
-		switch len(parts) {
-		case 1:
-			d.SetId(parts[0])
-			return []*schema.ResourceData{d}, nil
-		case 2:
-			d.Set("project_id", parts[0])
-			d.SetId(parts[1])
-			return []*schema.ResourceData{d}, nil
-		default:
-			return nil, fmt.Errorf("please provide resource identifier in format 'project_id:%s' or '%s'", identifierName, identifierName)
-		}
-	}
-}
-
-func MetakubeResourceSystemLabelOrTag(key string) bool {
-	for _, s := range []string{"labels.%", "metakube", "system-", "system/", "kubernetes.io"} {
-		if strings.Contains(key, s) {
-			return true
-		}
-	}
-	return false
-}
-
 func MetakubeGetCluster(ctx context.Context, proj, cls string, k *MetaKubeProviderMeta) (*models.Cluster, bool, error) {
 	p := project.NewGetClusterV2Params().
 		WithContext(ctx).
@@ -203,15 +162,38 @@ func MetakubeResourceClusterFindProjectID(ctx context.Context, id string, meta *
 		}
 	}
 
-	meta.Log.Infof("owner project for cluster with id '%s' not found", id)
+	meta.Log.Infof(ctx, "owner project for cluster with id '%s' not found", id)
 	return "", nil
 }
 
+// MetakubeListProjectIDs returns the projects to list resources of: projectID
+// when set, otherwise the default project of the provider profile, otherwise
+// every project the provider has access to.
+func MetakubeListProjectIDs(ctx context.Context, projectID string, meta *MetaKubeProviderMeta) ([]string, error) {
+	if projectID == "" {
+		projectID = meta.DefaultProjectID
+	}
+	if projectID != "" {
+		return []string{projectID}, nil
+	}
+
+	res, err := meta.Client.Project.ListProjects(project.NewListProjectsParams().WithContext(ctx), meta.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %s", StringifyResponseError(err))
+	}
+
+	ids := make([]string, 0, len(res.Payload))
+	for _, p := range res.Payload {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
 func metakubeResourceClusterBelongsToProject(ctx context.Context, prj, id string, meta *MetaKubeProviderMeta) (bool, error) {
 	prms := project.NewListClustersV2Params().WithContext(ctx).WithProjectID(prj)
 	res, err := meta.Client.Project.ListClustersV2(prms, meta.Auth)
 	if err != nil {
-		meta.Log.Debugf("lookup owner project: list clusters: %v", err)
+		meta.Log.Debugf(ctx, "lookup owner project: list clusters: %v", err)
 		return false, fmt.Errorf("list clusters: %s", StringifyResponseError(err))
 	}
 	for _, item := range res.Payload {
@@ -222,6 +204,25 @@ func metakubeResourceClusterBelongsToProject(ctx context.Context, prj, id string
 	return false, nil
 }
 
+// Health of a cluster component as reported by the MetaKube API.
+const (
+	HealthStatusDown         models.HealthStatus = 0
+	HealthStatusUp           models.HealthStatus = 1
+	HealthStatusProvisioning models.HealthStatus = 2
+)
+
+// ClusterHealthy reports whether all components of a cluster are up.
+func ClusterHealthy(h *models.ClusterHealth) bool {
+	return h != nil &&
+		h.Apiserver == HealthStatusUp &&
+		h.CloudProviderInfrastructure == HealthStatusUp &&
+		h.Controller == HealthStatusUp &&
+		h.Etcd == HealthStatusUp &&
+		h.MachineController == HealthStatusUp &&
+		h.Scheduler == HealthStatusUp &&
+		h.UserClusterControllerManager == HealthStatusUp
+}
+
 func MetakubeResourceClusterWaitForReady(ctx context.Context, k *MetaKubeProviderMeta, timeout time.Duration, projectID, clusterID, configuredVersion string) error {
 	return RetryContext(ctx, timeout, func() *RetryError {
 
@@ -245,14 +246,7 @@ func MetakubeResourceClusterWaitForReady(ctx context.Context, k *MetaKubeProvide
 			return RetryableError(fmt.Errorf("unable to get cluster '%s' health: %s", clusterID, StringifyResponseError(err)))
 		}
 
-		const up models.HealthStatus = 1
-		if clusterHealth.Payload.Apiserver == up &&
-			clusterHealth.Payload.CloudProviderInfrastructure == up &&
-			clusterHealth.Payload.Controller == up &&
-			clusterHealth.Payload.Etcd == up &&
-			clusterHealth.Payload.MachineController == up &&
-			clusterHealth.Payload.Scheduler == up &&
-			clusterHealth.Payload.UserClusterControllerManager == up {
+		if ClusterHealthy(clusterHealth.Payload) {
 			if configuredVersion == "" {
 				return nil
 			} else if cluster.Payload.Status.Version == models.Semver(configuredVersion) {
@@ -260,7 +254,93 @@ func MetakubeResourceClusterWaitForReady(ctx context.Context, k *MetaKubeProvide
 			}
 		}
 
-		k.Log.Debugf("waiting for cluster '%s' to be ready, %+v", clusterID, clusterHealth.Payload)
+		k.Log.Debugf(ctx, "waiting for cluster '%s' to be ready, %+v", clusterID, clusterHealth.Payload)
 		return RetryableError(fmt.Errorf("waiting for cluster '%s' to be ready", clusterID))
 	})
 }
+
+// MetakubeNodeDeploymentWaitForReady waits until all replicas of a node deployment
+// are available and all of its nodes have joined the cluster.
+func MetakubeNodeDeploymentWaitForReady(ctx context.Context, k *MetaKubeProviderMeta, timeout time.Duration, projectID, clusterID, nodeDeploymentID string) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for node deployment '%s' to be ready", nodeDeploymentID)
+		}
+
+		p := project.NewGetMachineDeploymentParams().
+			WithContext(ctx).
+			WithProjectID(projectID).
+			WithClusterID(clusterID).
+			WithMachineDeploymentID(nodeDeploymentID)
+
+		resp, err := k.Client.Project.GetMachineDeployment(p, k.Auth)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				continue
+			}
+		}
+
+		nd := resp.Payload
+		if nd.Spec.Replicas == nil || nd.Status == nil ||
+			nd.Status.ReadyReplicas < *nd.Spec.Replicas ||
+			nd.Status.UnavailableReplicas != 0 {
+			k.Log.Debugf(ctx, "waiting for node deployment '%s' to be ready, %+v", nodeDeploymentID, nd.Status)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Second):
+				continue
+			}
+		}
+
+		p2 := project.NewListMachineDeploymentNodesParams().
+			WithContext(ctx).
+			WithProjectID(projectID).
+			WithClusterID(clusterID).
+			WithMachineDeploymentID(nodeDeploymentID)
+		nodesResp, err := k.Client.Project.ListMachineDeploymentNodes(p2, k.Auth)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				continue
+			}
+		}
+
+		if len(nodesResp.Payload) != int(*nd.Spec.Replicas) {
+			k.Log.Debugf(ctx, "node count mismatch, want %v got %v", *nd.Spec.Replicas, len(nodesResp.Payload))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Second):
+				continue
+			}
+		}
+
+		allReady := true
+		for _, node := range nodesResp.Payload {
+			if node.Status == nil || node.Status.NodeInfo == nil || node.Status.NodeInfo.KernelVersion == "" {
+				allReady = false
+				break
+			}
+		}
+
+		if !allReady {
+			k.Log.Debugf(ctx, "found not ready node")
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Second):
+				continue
+			}
+		}
+
+		return nil
+	}
+}
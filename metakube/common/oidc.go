@@ -0,0 +1,241 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// oidcExpiryDelta is how long before its expiry an access token is refreshed.
+	oidcExpiryDelta = 30 * time.Second
+	// oidcRequestTimeout bounds discovery and token requests to the issuer,
+	// all API calls wait for them while Token holds the lock.
+	oidcRequestTimeout = 30 * time.Second
+)
+
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+// Enabled reports whether OIDC authentication was configured.
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// Validate checks that enough settings are present to obtain a token,
+// either via the refresh token grant or via the client credentials grant.
+func (c OIDCConfig) Validate() error {
+	if c.ClientID == "" {
+		return NewAuthError("missing OIDC client ID: 'oidc_client_id' is required when 'oidc_issuer_url' is set", "oidc_client_id")
+	}
+	if c.RefreshToken == "" && c.ClientSecret == "" {
+		return NewAuthError(
+			"missing OIDC credentials: provide either 'oidc_refresh_token' or 'oidc_client_secret'",
+			"oidc_refresh_token", "oidc_client_secret",
+		)
+	}
+	return nil
+}
+
+// OIDCTokenSource hands out access tokens obtained from an OIDC issuer. Tokens
+// are cached and refreshed shortly before they expire. The token endpoint is
+// discovered on first use, so configuring the provider does not need network access.
+type OIDCTokenSource struct {
+	config     OIDCConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	tokenEndpoint string
+	refreshToken  string
+	token         *oauth2.Token
+}
+
+func NewOIDCTokenSource(config OIDCConfig, httpClient *http.Client) (*OIDCTokenSource, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &OIDCTokenSource{
+		config:       config,
+		httpClient:   httpClient,
+		refreshToken: config.RefreshToken,
+	}, nil
+}
+
+// Token returns a cached access token, fetching a new one when there is none
+// or it is about to expire.
+func (s *OIDCTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken != "" &&
+		(s.token.Expiry.IsZero() || time.Now().Add(oidcExpiryDelta).Before(s.token.Expiry)) {
+		return s.token, nil
+	}
+
+	token, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// Invalidate drops the cached token if it is still the given one, forcing the
+// next call to Token to fetch a new access token.
+func (s *OIDCTokenSource) Invalidate(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken == accessToken {
+		s.token = nil
+	}
+}
+
+func (s *OIDCTokenSource) fetch() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), oidcRequestTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+
+	if s.tokenEndpoint == "" {
+		endpoint, err := discoverTokenEndpoint(ctx, s.httpClient, s.config.IssuerURL)
+		if err != nil {
+			return nil, NewAuthError(fmt.Sprintf("OIDC discovery failed: %v", err), "oidc_issuer_url")
+		}
+		s.tokenEndpoint = endpoint
+	}
+
+	if s.refreshToken != "" {
+		conf := &oauth2.Config{
+			ClientID:     s.config.ClientID,
+			ClientSecret: s.config.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: s.tokenEndpoint},
+		}
+		token, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: s.refreshToken}).Token()
+		if err != nil {
+			return nil, NewAuthError(fmt.Sprintf("failed to refresh OIDC token: %v", err), "oidc_refresh_token")
+		}
+		// Issuers may rotate refresh tokens, the old one is then no longer valid.
+		if token.RefreshToken != "" {
+			s.refreshToken = token.RefreshToken
+		}
+		return token, nil
+	}
+
+	conf := &clientcredentials.Config{
+		ClientID:     s.config.ClientID,
+		ClientSecret: s.config.ClientSecret,
+		TokenURL:     s.tokenEndpoint,
+		Scopes:       []string{"openid"},
+	}
+	token, err := conf.Token(ctx)
+	if err != nil {
+		return nil, NewAuthError(fmt.Sprintf("failed to obtain OIDC token via client credentials: %v", err), "oidc_client_id", "oidc_client_secret")
+	}
+	return token, nil
+}
+
+func discoverTokenEndpoint(ctx context.Context, client *http.Client, issuerURL string) (string, error) {
+	wellKnown := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", wellKnown, resp.Status)
+	}
+
+	var discovery struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return "", fmt.Errorf("can't decode %s: %v", wellKnown, err)
+	}
+	if discovery.TokenEndpoint == "" {
+		return "", fmt.Errorf("%s does not announce a token_endpoint", wellKnown)
+	}
+	return discovery.TokenEndpoint, nil
+}
+
+// NewOIDCAuth returns a ClientAuthInfoWriter that authenticates each request
+// with a current access token from the source.
+func NewOIDCAuth(source *OIDCTokenSource, terraformVersion string) runtime.ClientAuthInfoWriter {
+	return runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
+		token, err := source.Token()
+		if err != nil {
+			return err
+		}
+		if err := r.SetHeaderParam("Authorization", "Bearer "+token.AccessToken); err != nil {
+			return err
+		}
+		return r.SetHeaderParam("User-Agent", fmt.Sprintf("Terraform/%s", terraformVersion))
+	})
+}
+
+// oidcTransport retries a request once with a freshly obtained token when the
+// API rejects the current one, e.g. because it was revoked before its expiry.
+type oidcTransport struct {
+	base   http.RoundTripper
+	source *OIDCTokenSource
+}
+
+func NewOIDCTransport(base http.RoundTripper, source *OIDCTokenSource) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &oidcTransport{base: base, source: source}
+}
+
+func (t *oidcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	stale := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	t.source.Invalidate(stale)
+	token, tokenErr := t.source.Token()
+	if tokenErr != nil || token.AccessToken == stale {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
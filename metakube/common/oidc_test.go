@@ -0,0 +1,206 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestIssuer returns an OIDC issuer handing out "access-<n>" tokens that
+// expire after expiresIn seconds, rotating the refresh token on every request.
+func newTestIssuer(t *testing.T, expiresIn int) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var issued int32
+	var refreshTokens []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"token_endpoint": srv.URL + "/token"})
+		case "/token":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("can't parse token request: %v", err)
+			}
+			refreshTokens = append(refreshTokens, r.PostForm.Get("refresh_token"))
+			n := atomic.AddInt32(&issued, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  fmt.Sprintf("access-%d", n),
+				"token_type":    "Bearer",
+				"refresh_token": fmt.Sprintf("refresh-%d", n),
+				"expires_in":    expiresIn,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &refreshTokens
+}
+
+func TestOIDCConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  OIDCConfig
+		wantErr bool
+	}{
+		{
+			name:    "missing client id",
+			config:  OIDCConfig{IssuerURL: "https://issuer", RefreshToken: "r"},
+			wantErr: true,
+		},
+		{
+			name:    "missing credentials",
+			config:  OIDCConfig{IssuerURL: "https://issuer", ClientID: "c"},
+			wantErr: true,
+		},
+		{
+			name:   "refresh token",
+			config: OIDCConfig{IssuerURL: "https://issuer", ClientID: "c", RefreshToken: "r"},
+		},
+		{
+			name:   "client credentials",
+			config: OIDCConfig{IssuerURL: "https://issuer", ClientID: "c", ClientSecret: "s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOIDCTokenSourceRefresh(t *testing.T) {
+	tests := []struct {
+		name          string
+		expiresIn     int
+		wantTokens    []string
+		wantRefreshes []string
+	}{
+		{
+			name:          "cached while valid",
+			expiresIn:     3600,
+			wantTokens:    []string{"access-1", "access-1"},
+			wantRefreshes: []string{"initial"},
+		},
+		{
+			name:          "refreshed with rotated refresh token when about to expire",
+			expiresIn:     10,
+			wantTokens:    []string{"access-1", "access-2"},
+			wantRefreshes: []string{"initial", "refresh-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, refreshTokens := newTestIssuer(t, tt.expiresIn)
+			source, err := NewOIDCTokenSource(OIDCConfig{
+				IssuerURL:    srv.URL,
+				ClientID:     "client",
+				RefreshToken: "initial",
+			}, srv.Client())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for range tt.wantTokens {
+				token, err := source.Token()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got = append(got, token.AccessToken)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantTokens, ",") {
+				t.Errorf("expected tokens %v, got %v", tt.wantTokens, got)
+			}
+			if strings.Join(*refreshTokens, ",") != strings.Join(tt.wantRefreshes, ",") {
+				t.Errorf("expected refresh tokens %v, got %v", tt.wantRefreshes, *refreshTokens)
+			}
+		})
+	}
+}
+
+func TestOIDCTransportRetriesOnce(t *testing.T) {
+	tests := []struct {
+		name         string
+		rejectAll    bool
+		body         io.Reader
+		wantStatus   int
+		wantRequests []string
+	}{
+		{
+			name:         "retried with a new token",
+			wantStatus:   http.StatusOK,
+			wantRequests: []string{"Bearer access-1", "Bearer access-2"},
+		},
+		{
+			name:         "retried with body",
+			body:         strings.NewReader("{}"),
+			wantStatus:   http.StatusOK,
+			wantRequests: []string{"Bearer access-1", "Bearer access-2"},
+		},
+		{
+			name:         "second rejection returned",
+			rejectAll:    true,
+			wantStatus:   http.StatusUnauthorized,
+			wantRequests: []string{"Bearer access-1", "Bearer access-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, _ := newTestIssuer(t, 3600)
+			source, err := NewOIDCTokenSource(OIDCConfig{
+				IssuerURL:    issuer.URL,
+				ClientID:     "client",
+				RefreshToken: "initial",
+			}, issuer.Client())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var requests []string
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Header.Get("Authorization"))
+				if tt.rejectAll || len(requests) == 1 {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer api.Close()
+
+			token, err := source.Token()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req, err := http.NewRequest(http.MethodPost, api.URL, tt.body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+			resp, err := NewOIDCTransport(api.Client().Transport, source).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if strings.Join(requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("expected requests %v, got %v", tt.wantRequests, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/syseleven/go-metakube/client/users"
+)
+
+const (
+	// DefaultTokenExpiryWarning is how long before its expiry a token is warned about.
+	DefaultTokenExpiryWarning = 30 * time.Minute
+
+	preflightTimeout = 30 * time.Second
+)
+
+// PreflightConfig describes the connection checked by Preflight.
+type PreflightConfig struct {
+	Host      string
+	Transport http.RoundTripper
+	Auth      runtime.ClientAuthInfoWriter
+	// TokenRefreshes is set when tokens are obtained from OIDC or a token
+	// command, which replace them before they expire.
+	TokenRefreshes bool
+	// ExpiryWarning is the window before the token expiry in which a warning is returned.
+	ExpiryWarning time.Duration
+}
+
+// Preflight fetches the current user to find out early whether the API is
+// reachable and accepts the token. Connection and authentication problems are
+// returned as errors naming the likely cause, a static token expiring within
+// the warning window as a warning.
+func Preflight(ctx context.Context, config PreflightConfig) fwdiag.Diagnostics {
+	capture := &tokenCapture{base: config.Transport}
+	client, diags := NewClient(config.Host, capture)
+	if diags.HasError() {
+		return diags
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	params := users.NewGetCurrentUserParams().WithContext(ctx)
+	_, err := client.Users.GetCurrentUser(params, config.Auth)
+	token := capture.Token()
+	if err != nil {
+		diags.Append(preflightErrorDiagnostics(config.Host, token, err)...)
+		return diags
+	}
+
+	if config.TokenRefreshes || config.ExpiryWarning <= 0 {
+		return diags
+	}
+	if expiry, ok := TokenExpiry(token); ok && time.Until(expiry) < config.ExpiryWarning {
+		diags.AddWarning(
+			"MetaKube Token Expires Soon",
+			fmt.Sprintf("The MetaKube token expires at %s, in %s. Operations running past that time will fail, "+
+				"obtain a new token or use token_command or OIDC authentication to refresh it automatically.",
+				expiry.Format(time.RFC3339), time.Until(expiry).Round(time.Second)),
+		)
+	}
+
+	return diags
+}
+
+func preflightErrorDiagnostics(host, token string, err error) fwdiag.Diagnostics {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return ToFrameworkDiagnostics(err)
+	}
+
+	var diags fwdiag.Diagnostics
+
+	var (
+		certErr     *tls.CertificateVerificationError
+		unknownAuth x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+		recordErr   tls.RecordHeaderError
+		dnsErr      *net.DNSError
+		opErr       *net.OpError
+	)
+	switch {
+	case errors.As(err, &certErr), errors.As(err, &unknownAuth), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr):
+		diags.AddError(
+			"MetaKube API TLS Error",
+			fmt.Sprintf("The TLS connection to %s could not be established: %v. Check ca_file, ca_pem, client_cert and client_key, "+
+				"or whether a proxy intercepts the connection.", host, err),
+		)
+		return diags
+	case errors.As(err, &dnsErr), errors.As(err, &opErr), errors.Is(err, context.DeadlineExceeded):
+		diags.AddError(
+			"Unable to Reach MetaKube API",
+			fmt.Sprintf("The MetaKube API at %s could not be reached: %v. Check host and proxy_url.", host, err),
+		)
+		return diags
+	}
+
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) {
+		switch coder.Code() {
+		case http.StatusUnauthorized:
+			message := "The MetaKube API rejected the token."
+			if expiry, ok := TokenExpiry(token); ok && time.Now().After(expiry) {
+				diags.AddError(
+					"MetaKube Token Expired",
+					fmt.Sprintf("%s It expired at %s, obtain a new token.", message, expiry.Format(time.RFC3339)),
+				)
+				return diags
+			} else if ok {
+				message = fmt.Sprintf("%s It is valid until %s, check that it was issued for %s.", message, expiry.Format(time.RFC3339), host)
+			}
+			diags.AddError("MetaKube Token Rejected", message)
+			return diags
+		case http.StatusForbidden:
+			diags.AddError(
+				"MetaKube Access Denied",
+				fmt.Sprintf("The MetaKube API accepted the token but denied access: %s", StringifyResponseError(err)),
+			)
+			return diags
+		}
+	}
+
+	diags.AddError(
+		"MetaKube API Error",
+		fmt.Sprintf("Failed to get the current user: %s", StringifyResponseError(err)),
+	)
+	return diags
+}
+
+// TokenExpiry returns the expiry of a JWT without verifying its signature.
+func TokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
+
+// tokenCapture remembers the bearer token sent with the last request.
+type tokenCapture struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	token string
+}
+
+func (t *tokenCapture) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		t.mu.Lock()
+		t.token = token
+		t.mu.Unlock()
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *tokenCapture) Token() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// testJWT returns an unsigned JWT with the given payload.
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(payload)) + ".signature"
+}
+
+// summaries returns the summaries of diags joined by ", ".
+func summaries(diags fwdiag.Diagnostics) string {
+	var s []string
+	for _, d := range diags {
+		s = append(s, d.Summary())
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestTokenExpiry(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "exp", token: testJWT(`{"sub":"user","exp":1893456000}`), want: time.Unix(1893456000, 0), wantOK: true},
+		{name: "fractional exp", token: testJWT(`{"exp":1893456000.5}`), want: time.Unix(1893456000, 0), wantOK: true},
+		{name: "padded payload", token: "e30." + base64.URLEncoding.EncodeToString([]byte(`{"exp":10}`)) + ".sig", want: time.Unix(10, 0), wantOK: true},
+		{name: "no exp", token: testJWT(`{"sub":"user"}`)},
+		{name: "exp not a number", token: testJWT(`{"exp":"tomorrow"}`)},
+		{name: "payload not JSON", token: testJWT(`exp`)},
+		{name: "opaque token", token: "abcdef"},
+		{name: "payload not base64", token: "a.!!!.c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TokenExpiry(tt.token)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("expected %v %v, got %v %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	soon := time.Now().Add(10 * time.Minute).Unix()
+	later := time.Now().Add(24 * time.Hour).Unix()
+	expired := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name           string
+		status         int
+		token          string
+		tokenRefreshes bool
+		unreachable    bool
+		wantError      string
+		wantWarning    string
+	}{
+		{
+			name:   "valid token",
+			status: http.StatusOK,
+			token:  testJWT(fmt.Sprintf(`{"exp":%d}`, later)),
+		},
+		{
+			name:        "token expires soon",
+			status:      http.StatusOK,
+			token:       testJWT(fmt.Sprintf(`{"exp":%d}`, soon)),
+			wantWarning: "MetaKube Token Expires Soon",
+		},
+		{
+			name:           "refreshed token expires soon",
+			status:         http.StatusOK,
+			token:          testJWT(fmt.Sprintf(`{"exp":%d}`, soon)),
+			tokenRefreshes: true,
+		},
+		{
+			name:      "expired token",
+			status:    http.StatusUnauthorized,
+			token:     testJWT(fmt.Sprintf(`{"exp":%d}`, expired)),
+			wantError: "MetaKube Token Expired",
+		},
+		{
+			name:      "rejected token",
+			status:    http.StatusUnauthorized,
+			token:     "opaque",
+			wantError: "MetaKube Token Rejected",
+		},
+		{
+			name:      "forbidden",
+			status:    http.StatusForbidden,
+			token:     "opaque",
+			wantError: "MetaKube Access Denied",
+		},
+		{
+			name:        "unreachable",
+			token:       "opaque",
+			unreachable: true,
+			wantError:   "Unable to Reach MetaKube API",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/me" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			host := srv.URL
+			if tt.unreachable {
+				srv.Close()
+			} else {
+				defer srv.Close()
+			}
+
+			auth, err := NewAuth(tt.token, "", nil, "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			diags := Preflight(context.Background(), PreflightConfig{
+				Host:           host,
+				Transport:      http.DefaultTransport,
+				Auth:           auth,
+				TokenRefreshes: tt.tokenRefreshes,
+				ExpiryWarning:  DefaultTokenExpiryWarning,
+			})
+
+			if got := summaries(diags.Errors()); got != tt.wantError {
+				t.Errorf("expected error %q, got %q", tt.wantError, got)
+			}
+			if got := summaries(diags.Warnings()); got != tt.wantWarning {
+				t.Errorf("expected warning %q, got %q", tt.wantWarning, got)
+			}
+		})
+	}
+}
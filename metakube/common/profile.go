@@ -0,0 +1,230 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultHost       = "https://metakube.syseleven.de"
+	DefaultTokenPath  = "~/.metakube/auth"
+	DefaultConfigPath = "~/.metakube/config"
+)
+
+type ProfileError struct {
+	Message    string
+	Attributes []string
+}
+
+func (e *ProfileError) Error() string {
+	return e.Message
+}
+
+func NewProfileError(message string, attributes ...string) *ProfileError {
+	return &ProfileError{
+		Message:    message,
+		Attributes: attributes,
+	}
+}
+
+// ConfigFile is the MetaKube config file, by default ~/.metakube/config.
+type ConfigFile struct {
+	DefaultProfile string              `yaml:"default_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+type Profile struct {
+	Host         string   `yaml:"host"`
+	Token        string   `yaml:"token"`
+	TokenPath    string   `yaml:"token_path"`
+	TokenCommand []string `yaml:"token_command"`
+	ProjectID    string   `yaml:"project_id"`
+}
+
+// ConnectionSettings are the settings the provider needs to talk to the MetaKube API.
+type ConnectionSettings struct {
+	Profile          string
+	Host             string
+	Token            string
+	TokenPath        string
+	TokenCommand     []string
+	DefaultProjectID string
+}
+
+// ResolveConnectionSettings completes the explicitly configured settings.
+//
+// Precedence, highest first:
+//  1. provider attributes
+//  2. the profile selected by `profile` or METAKUBE_PROFILE
+//  3. environment variables (METAKUBE_HOST, METAKUBE_TOKEN, ...)
+//  4. default_profile in the config file
+//  5. built-in defaults
+//
+// An explicitly selected profile replaces the environment variables as a whole,
+// so a host and token meant for different endpoints are never combined.
+// Credentials are taken as a whole as well: if any of token, token_command or
+// token_path is set, lower sources' credentials are ignored.
+func ResolveConnectionSettings(explicit ConnectionSettings) (ConnectionSettings, error) {
+	s := explicit
+	if s.Profile == "" {
+		s.Profile = os.Getenv("METAKUBE_PROFILE")
+	}
+
+	profile, err := loadProfile(s.Profile)
+	if err != nil {
+		return s, err
+	}
+	if profile != nil && s.Profile != "" {
+		applyProfile(&s, profile)
+	} else {
+		applyEnv(&s)
+		if profile != nil {
+			applyProfile(&s, profile)
+		}
+	}
+
+	if s.Host == "" {
+		s.Host = DefaultHost
+	}
+	if !s.hasCredentials() {
+		s.TokenPath = DefaultTokenPath
+	}
+
+	return s, nil
+}
+
+func (s *ConnectionSettings) hasCredentials() bool {
+	return s.Token != "" || s.TokenPath != "" || len(s.TokenCommand) > 0
+}
+
+// applyEnv fills the settings not set yet from the environment variables.
+func applyEnv(s *ConnectionSettings) {
+	if s.Host == "" {
+		s.Host = os.Getenv("METAKUBE_HOST")
+	}
+	if s.Token == "" {
+		s.Token = os.Getenv("METAKUBE_TOKEN")
+	}
+	if s.TokenPath == "" {
+		s.TokenPath = os.Getenv("METAKUBE_TOKEN_PATH")
+	}
+	if len(s.TokenCommand) == 0 {
+		s.TokenCommand = strings.Fields(os.Getenv("METAKUBE_TOKEN_COMMAND"))
+	}
+}
+
+// applyProfile fills the settings not set yet from profile.
+func applyProfile(s *ConnectionSettings, profile *Profile) {
+	if s.Host == "" {
+		s.Host = profile.Host
+	}
+	if !s.hasCredentials() {
+		s.Token = profile.Token
+		s.TokenPath = profile.TokenPath
+		s.TokenCommand = profile.TokenCommand
+	}
+	s.DefaultProjectID = profile.ProjectID
+}
+
+// loadProfile returns the named profile from the config file at METAKUBE_CONFIG
+// or DefaultConfigPath. Without a name the file's default_profile is used, if any.
+// A missing config file is only an error if a profile was asked for.
+func loadProfile(name string) (*Profile, error) {
+	configPath := os.Getenv("METAKUBE_CONFIG")
+	if configPath == "" {
+		configPath = DefaultConfigPath
+	}
+	expandedPath, err := homedir.Expand(configPath)
+	if err != nil {
+		return nil, NewProfileError(fmt.Sprintf("failed to expand config path: %v", err), "profile")
+	}
+
+	raw, err := os.ReadFile(expandedPath)
+	if errors.Is(err, os.ErrNotExist) && name == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewProfileError(fmt.Sprintf("failed to read config file: %v", err), "profile")
+	}
+
+	var config ConfigFile
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, NewProfileError(fmt.Sprintf("failed to parse config file %s: %v", configPath, err), "profile")
+	}
+
+	if name == "" {
+		name = config.DefaultProfile
+		if name == "" {
+			return nil, nil
+		}
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok || profile == nil {
+		return nil, NewProfileError(fmt.Sprintf("profile %q not found in %s", name, configPath), "profile")
+	}
+	return profile, nil
+}
+
+// PlanDefaultProjectID defaults project_id of resources about to be created to
+// the project of the selected provider profile.
+func (k *MetaKubeProviderMeta) PlanDefaultProjectID(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) fwdiag.Diagnostics {
+	var diags fwdiag.Diagnostics
+	if k == nil || !req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return diags
+	}
+
+	var projectID types.String
+	diags.Append(req.Config.GetAttribute(ctx, path.Root("project_id"), &projectID)...)
+	if diags.HasError() || !projectID.IsNull() {
+		return diags
+	}
+
+	if k.DefaultProjectID == "" {
+		diags.AddAttributeError(
+			path.Root("project_id"),
+			"Missing project ID",
+			"Either set project_id or select a provider profile with a project_id.",
+		)
+		return diags
+	}
+
+	diags.Append(resp.Plan.SetAttribute(ctx, path.Root("project_id"), k.DefaultProjectID)...)
+	return diags
+}
+
+func ProfileToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var diags fwdiag.Diagnostics
+	var profileErr *ProfileError
+	if errors.As(err, &profileErr) {
+		for _, attr := range profileErr.Attributes {
+			diags.AddAttributeError(
+				path.Root(attr),
+				"Profile Configuration Error",
+				profileErr.Message,
+			)
+		}
+
+		if len(profileErr.Attributes) == 0 {
+			diags.AddError("Profile Configuration Error", profileErr.Message)
+		}
+	} else {
+		diags.AddError("Profile Configuration Error", err.Error())
+	}
+
+	return diags
+}
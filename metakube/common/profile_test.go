@@ -0,0 +1,236 @@
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const testConfigFile = `default_profile: staging
+profiles:
+  staging:
+    host: https://staging.example.com
+    token_path: /staging-auth
+    project_id: staging-project
+  production:
+    host: https://production.example.com
+    token_command: ["get-token", "production"]
+    project_id: production-project
+  host-only:
+    host: https://host-only.example.com
+`
+
+func TestResolveConnectionSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		explicit ConnectionSettings
+		want     ConnectionSettings
+		wantErr  bool
+	}{
+		{
+			name: "defaults without config file",
+			want: ConnectionSettings{Host: DefaultHost, TokenPath: DefaultTokenPath},
+		},
+		{
+			name:   "default profile",
+			config: testConfigFile,
+			want: ConnectionSettings{
+				Host:             "https://staging.example.com",
+				TokenPath:        "/staging-auth",
+				DefaultProjectID: "staging-project",
+			},
+		},
+		{
+			name:   "env beats default profile",
+			config: testConfigFile,
+			env:    map[string]string{"METAKUBE_HOST": "https://env.example.com", "METAKUBE_TOKEN": "env-token"},
+			want: ConnectionSettings{
+				Host:             "https://env.example.com",
+				Token:            "env-token",
+				DefaultProjectID: "staging-project",
+			},
+		},
+		{
+			name:     "selected profile beats env",
+			config:   testConfigFile,
+			env:      map[string]string{"METAKUBE_HOST": "https://env.example.com", "METAKUBE_TOKEN": "env-token"},
+			explicit: ConnectionSettings{Profile: "production"},
+			want: ConnectionSettings{
+				Profile:          "production",
+				Host:             "https://production.example.com",
+				TokenCommand:     []string{"get-token", "production"},
+				DefaultProjectID: "production-project",
+			},
+		},
+		{
+			name:   "profile selected by env beats env",
+			config: testConfigFile,
+			env:    map[string]string{"METAKUBE_PROFILE": "production", "METAKUBE_TOKEN": "env-token"},
+			want: ConnectionSettings{
+				Profile:          "production",
+				Host:             "https://production.example.com",
+				TokenCommand:     []string{"get-token", "production"},
+				DefaultProjectID: "production-project",
+			},
+		},
+		{
+			name:     "selected profile without credentials ignores env token",
+			config:   testConfigFile,
+			env:      map[string]string{"METAKUBE_TOKEN": "env-token"},
+			explicit: ConnectionSettings{Profile: "host-only"},
+			want: ConnectionSettings{
+				Profile:   "host-only",
+				Host:      "https://host-only.example.com",
+				TokenPath: DefaultTokenPath,
+			},
+		},
+		{
+			name:     "attributes beat selected profile",
+			config:   testConfigFile,
+			explicit: ConnectionSettings{Profile: "production", Token: "attribute-token"},
+			want: ConnectionSettings{
+				Profile:          "production",
+				Host:             "https://production.example.com",
+				Token:            "attribute-token",
+				DefaultProjectID: "production-project",
+			},
+		},
+		{
+			name:     "unknown profile",
+			config:   testConfigFile,
+			explicit: ConnectionSettings{Profile: "missing"},
+			wantErr:  true,
+		},
+		{
+			name:     "profile without config file",
+			explicit: ConnectionSettings{Profile: "production"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config")
+			if tt.config != "" {
+				if err := os.WriteFile(configPath, []byte(tt.config), 0o600); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			t.Setenv("METAKUBE_CONFIG", configPath)
+			for _, name := range []string{"METAKUBE_PROFILE", "METAKUBE_HOST", "METAKUBE_TOKEN", "METAKUBE_TOKEN_PATH", "METAKUBE_TOKEN_COMMAND"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			got, err := ResolveConnectionSettings(tt.explicit)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("settings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanDefaultProjectID(t *testing.T) {
+	ctx := context.Background()
+	s := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"project_id": schema.StringAttribute{Optional: true, Computed: true},
+		},
+	}
+	typ := s.Type().TerraformType(ctx)
+	object := func(projectID interface{}) tftypes.Value {
+		return tftypes.NewValue(typ, map[string]tftypes.Value{"project_id": tftypes.NewValue(tftypes.String, projectID)})
+	}
+
+	tests := []struct {
+		name             string
+		defaultProjectID string
+		configured       interface{}
+		state            tftypes.Value
+		want             string
+		wantErr          bool
+	}{
+		{
+			name:             "default project",
+			defaultProjectID: "profile-project",
+			state:            tftypes.NewValue(typ, nil),
+			want:             "profile-project",
+		},
+		{
+			name:             "configured project",
+			defaultProjectID: "profile-project",
+			configured:       "configured-project",
+			state:            tftypes.NewValue(typ, nil),
+			want:             "configured-project",
+		},
+		{
+			name:    "no default project",
+			state:   tftypes.NewValue(typ, nil),
+			wantErr: true,
+		},
+		{
+			name:             "existing resource",
+			defaultProjectID: "profile-project",
+			state:            object("state-project"),
+			want:             "state-project",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planned := object(tt.configured)
+			if tt.configured == nil {
+				planned = object(tftypes.UnknownValue)
+				if !tt.state.IsNull() {
+					planned = tt.state
+				}
+			}
+			req := resource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: s, Raw: object(tt.configured)},
+				Plan:   tfsdk.Plan{Schema: s, Raw: planned},
+				State:  tfsdk.State{Schema: s, Raw: tt.state},
+			}
+			resp := &resource.ModifyPlanResponse{Plan: req.Plan}
+
+			meta := &MetaKubeProviderMeta{DefaultProjectID: tt.defaultProjectID}
+			diags := meta.PlanDefaultProjectID(ctx, req, resp)
+			if tt.wantErr {
+				if !diags.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			var got types.String
+			if diags := resp.Plan.GetAttribute(ctx, path.Root("project_id"), &got); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if got.ValueString() != tt.want {
+				t.Errorf("expected project_id %q, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// ReadOnlyError is returned for operations that would modify resources while
+// the provider is configured with read_only.
+type ReadOnlyError struct {
+	Message string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return e.Message
+}
+
+func NewReadOnlyError(message string) *ReadOnlyError {
+	return &ReadOnlyError{Message: message}
+}
+
+// CheckWritable returns a ReadOnlyError when the provider is read_only. It is
+// called at the start of every Create, Update and Delete, before any API call.
+func (k *MetaKubeProviderMeta) CheckWritable(operation, resourceType string) error {
+	if k == nil || !k.ReadOnly {
+		return nil
+	}
+	return NewReadOnlyError(fmt.Sprintf("The provider is configured with read_only, refusing to %s %s.", operation, resourceType))
+}
+
+// readOnlyTransport rejects every request that is not a GET or HEAD, as a
+// safety net for modifications that bypass CheckWritable.
+type readOnlyTransport struct {
+	base http.RoundTripper
+}
+
+// NewReadOnlyTransport wraps base so that only GET and HEAD requests are sent.
+func NewReadOnlyTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &readOnlyTransport{base: base}
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, NewReadOnlyError(fmt.Sprintf("read_only is set, refusing to send %s %s", req.Method, req.URL.Path))
+	}
+	return t.base.RoundTrip(req)
+}
+
+func ReadOnlyToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var diags fwdiag.Diagnostics
+	var readOnlyErr *ReadOnlyError
+	if errors.As(err, &readOnlyErr) {
+		diags.AddError("Read-Only Provider", readOnlyErr.Message)
+	} else {
+		diags.AddError("Read-Only Provider", err.Error())
+	}
+
+	return diags
+}
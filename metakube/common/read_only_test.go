@@ -0,0 +1,79 @@
+package common
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyTransport(t *testing.T) {
+	tests := []struct {
+		method   string
+		wantSent bool
+	}{
+		{method: http.MethodGet, wantSent: true},
+		{method: http.MethodHead, wantSent: true},
+		{method: http.MethodPost},
+		{method: http.MethodPut},
+		{method: http.MethodPatch},
+		{method: http.MethodDelete},
+		{method: http.MethodOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var sent bool
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				return testResponse(http.StatusOK, nil), nil
+			})
+
+			var body io.Reader
+			if !tt.wantSent {
+				body = strings.NewReader("{}")
+			}
+			req, err := http.NewRequest(tt.method, "https://metakube.example.com/api/v2/projects/p/clusters", body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = NewReadOnlyTransport(base).RoundTrip(req)
+			if sent != tt.wantSent {
+				t.Errorf("expected request sent %v, got %v", tt.wantSent, sent)
+			}
+			var readOnlyErr *ReadOnlyError
+			if tt.wantSent && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.wantSent && !errors.As(err, &readOnlyErr) {
+				t.Errorf("expected a ReadOnlyError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    *MetaKubeProviderMeta
+		wantErr bool
+	}{
+		{name: "not configured", meta: nil},
+		{name: "writable", meta: &MetaKubeProviderMeta{}},
+		{name: "read only", meta: &MetaKubeProviderMeta{ReadOnly: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.meta.CheckWritable("delete", "cluster")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if diags := ReadOnlyToFrameworkDiagnostics(err); diags.HasError() != tt.wantErr {
+				t.Errorf("expected error diagnostics %v, got %v", tt.wantErr, diags)
+			}
+		})
+	}
+}
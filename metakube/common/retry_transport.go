@@ -0,0 +1,120 @@
+package common
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultRetryMaxAttempts = 5
+
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+	// retryAfterMax bounds how long a Retry-After header can make us wait.
+	retryAfterMax = 2 * time.Minute
+)
+
+// retryTransport retries idempotent requests the API gateway rejected as
+// throttled or temporarily unavailable. It waits as long as the Retry-After
+// header asks for, otherwise an exponentially growing delay with full jitter.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	log         *Logger
+}
+
+// NewRetryTransport wraps base so that idempotent requests answered with 429,
+// 502, 503 or 504 are sent up to maxAttempts times in total.
+func NewRetryTransport(base http.RoundTripper, maxAttempts int, log *Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if log == nil {
+		log = NewNopLogger()
+	}
+	return &retryTransport{base: base, maxAttempts: maxAttempts, log: log}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req.Method) || (req.Body != nil && req.GetBody == nil) {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || attempt >= t.maxAttempts || !isRetryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		delay := retryDelay(resp, attempt)
+		t.log.Debugf(req.Context(), "%s %s returned %s, retrying in %s (attempt %d/%d)", req.Method, req.URL.Path, resp.Status, delay, attempt+1, t.maxAttempts)
+
+		// Drain the body so the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns the delay before the next attempt, preferring the
+// server's Retry-After over exponential backoff with full jitter.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return min(d, retryAfterMax)
+	}
+
+	backoff := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
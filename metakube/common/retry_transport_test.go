@@ -0,0 +1,184 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func testResponse(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "7", want: 7 * time.Second, wantOK: true},
+		{name: "zero", value: "0", wantOK: true},
+		{name: "negative", value: "-1"},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", wantOK: true},
+		{name: "invalid", value: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("expected %v %v, got %v %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		attempt    int
+		wantMax    time.Duration
+		wantExact  bool
+	}{
+		{name: "retry after", retryAfter: "3", attempt: 1, wantMax: 3 * time.Second, wantExact: true},
+		{name: "retry after capped", retryAfter: "3600", attempt: 1, wantMax: retryAfterMax, wantExact: true},
+		{name: "first backoff", attempt: 1, wantMax: retryBaseDelay},
+		{name: "growing backoff", attempt: 3, wantMax: 4 * retryBaseDelay},
+		{name: "backoff capped", attempt: 20, wantMax: retryMaxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			got := retryDelay(testResponse(http.StatusTooManyRequests, header), tt.attempt)
+			if got < 0 || got > tt.wantMax || (tt.wantExact && got != tt.wantMax) {
+				t.Errorf("expected a delay up to %v (exact %v), got %v", tt.wantMax, tt.wantExact, got)
+			}
+		})
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		body         func() io.Reader
+		statuses     []int
+		wantStatus   int
+		wantAttempts int
+	}{
+		{
+			name:         "GET retried until success",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 3,
+		},
+		{
+			name:         "GET gives up after max attempts",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			wantStatus:   http.StatusBadGateway,
+			wantAttempts: 3,
+		},
+		{
+			name:         "other errors are not retried",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusInternalServerError, http.StatusOK},
+			wantStatus:   http.StatusInternalServerError,
+			wantAttempts: 1,
+		},
+		{
+			name:         "PUT with replayable body retried",
+			method:       http.MethodPut,
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:         "PUT with body that can't be replayed is not retried",
+			method:       http.MethodPut,
+			body:         func() io.Reader { return io.MultiReader(strings.NewReader("{}")) },
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			wantStatus:   http.StatusTooManyRequests,
+			wantAttempts: 1,
+		},
+		{
+			name:         "POST is not retried",
+			method:       http.MethodPost,
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+		{
+			name:         "PATCH is not retried",
+			method:       http.MethodPatch,
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					if body, _ := io.ReadAll(req.Body); string(body) != "{}" {
+						t.Errorf("attempt %d sent body %q", attempts+1, body)
+					}
+				}
+				status := tt.statuses[attempts]
+				attempts++
+				// Retry-After keeps the test fast.
+				return testResponse(status, http.Header{"Retry-After": []string{"0"}}), nil
+			})
+
+			var body io.Reader
+			if tt.body != nil {
+				body = tt.body()
+			}
+			req, err := http.NewRequest(tt.method, "https://metakube.example.com/api/v1/projects", body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resp, err := NewRetryTransport(base, 3, nil).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
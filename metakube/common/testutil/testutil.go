@@ -1,7 +1,6 @@
 package testutil
 
 import (
-	"context"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -10,10 +9,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
-	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
-	"github.com/hashicorp/terraform-plugin-mux/tf5to6server"
-	"github.com/hashicorp/terraform-plugin-mux/tf6muxserver"
 	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
@@ -21,33 +17,10 @@ import (
 	"github.com/syseleven/go-metakube/models"
 	"github.com/syseleven/terraform-provider-metakube/metakube"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common"
-	"go.uber.org/zap"
 )
 
 var TestAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
-	"metakube": func() (tfprotov6.ProviderServer, error) {
-		ctx := context.Background()
-
-		upgradedSdkProvider, err := tf5to6server.UpgradeServer(
-			ctx,
-			func() tfprotov5.ProviderServer {
-				return metakube.Provider().GRPCProvider()
-			},
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		providers := []func() tfprotov6.ProviderServer{
-			providerserver.NewProtocol6(metakube.NewFrameworkProvider()),
-			func() tfprotov6.ProviderServer { return upgradedSdkProvider },
-		}
-		muxServer, err := tf6muxserver.NewMuxServer(ctx, providers...)
-		if err != nil {
-			return nil, err
-		}
-		return muxServer.ProviderServer(), nil
-	},
+	"metakube": providerserver.NewProtocol6WithError(metakube.NewProvider()),
 }
 
 const (
@@ -113,18 +86,18 @@ func TestAccPreCheckForOpenstack(t *testing.T) {
 
 func GetTestClient() (*common.MetaKubeProviderMeta, error) {
 	host := os.Getenv("METAKUBE_HOST")
-	client, diags := common.NewClient(host)
+	client, diags := common.NewClient(host, nil)
 	if diags.HasError() {
 		return nil, fmt.Errorf("create client: %v", diags.Errors())
 	}
 
 	token := os.Getenv(common.TestEnvServiceAccountCredential)
-	auth, authErr := common.NewAuth(token, "", "")
+	auth, authErr := common.NewAuth(token, "", nil, "")
 	if authErr != nil {
 		return nil, fmt.Errorf("auth api: %v", authErr)
 	}
 
-	log := zap.NewNop().Sugar()
+	log := common.NewNopLogger()
 
 	return &common.MetaKubeProviderMeta{
 		Client: client,
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenCommandTimeout bounds how long a token command may run.
+	tokenCommandTimeout = time.Minute
+	// tokenCommandExpiryDelta is how long before its expiry a token is renewed.
+	tokenCommandExpiryDelta = 30 * time.Second
+)
+
+// TokenCommand obtains the MetaKube token from an external executable,
+// similar to AWS credential_process or kubectl exec plugins.
+//
+// The command must print a JSON document to stdout, either
+//
+//	{"token": "...", "expiry": "2006-01-02T15:04:05Z"}
+//
+// or a Kubernetes ExecCredential with status.token and status.expirationTimestamp.
+// The expiry is optional, without it the token is used for the lifetime of the provider.
+type TokenCommand struct {
+	args []string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type tokenCommandOutput struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+	Status *struct {
+		Token               string    `json:"token"`
+		ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+func NewTokenCommand(args []string) *TokenCommand {
+	return &TokenCommand{args: args}
+}
+
+// Token returns the cached token, running the command when there is none yet
+// or the cached one is about to expire.
+func (c *TokenCommand) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Add(tokenCommandExpiryDelta).Before(c.expiry)) {
+		return c.token, nil
+	}
+
+	token, expiry, err := c.run()
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expiry = token, expiry
+	return token, nil
+}
+
+func (c *TokenCommand) run() (string, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := fmt.Sprintf("token command %q failed: %v", c.args[0], err)
+		if errOutput := strings.TrimSpace(stderr.String()); errOutput != "" {
+			msg += ": " + errOutput
+		}
+		return "", time.Time{}, NewAuthError(msg, "token_command")
+	}
+
+	var out tokenCommandOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return "", time.Time{}, NewAuthError(
+			fmt.Sprintf("can't parse output of token command %q: %v", c.args[0], err),
+			"token_command",
+		)
+	}
+
+	token, expiry := out.Token, out.Expiry
+	if token == "" && out.Status != nil {
+		token, expiry = out.Status.Token, out.Status.ExpirationTimestamp
+	}
+	if token == "" {
+		return "", time.Time{}, NewAuthError(
+			fmt.Sprintf("token command %q returned no token", c.args[0]),
+			"token_command",
+		)
+	}
+
+	return token, expiry, nil
+}
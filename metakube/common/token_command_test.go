@@ -0,0 +1,121 @@
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenCommandRun(t *testing.T) {
+	expiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		script     string
+		wantToken  string
+		wantExpiry time.Time
+		wantErr    string
+	}{
+		{
+			name:      "token without expiry",
+			script:    `echo '{"token": "abc"}'`,
+			wantToken: "abc",
+		},
+		{
+			name:       "token with expiry",
+			script:     `echo '{"token": "abc", "expiry": "2030-01-02T03:04:05Z"}'`,
+			wantToken:  "abc",
+			wantExpiry: expiry,
+		},
+		{
+			name:       "ExecCredential",
+			script:     `echo '{"kind": "ExecCredential", "apiVersion": "client.authentication.k8s.io/v1", "status": {"token": "def", "expirationTimestamp": "2030-01-02T03:04:05Z"}}'`,
+			wantToken:  "def",
+			wantExpiry: expiry,
+		},
+		{
+			name:    "no token",
+			script:  `echo '{"status": {}}'`,
+			wantErr: "returned no token",
+		},
+		{
+			name:    "invalid output",
+			script:  `echo 'abc'`,
+			wantErr: "can't parse output",
+		},
+		{
+			name:    "failing command",
+			script:  `echo 'not logged in' >&2; exit 1`,
+			wantErr: "not logged in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, gotExpiry, err := NewTokenCommand([]string{"sh", "-c", tt.script}).run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token != tt.wantToken {
+				t.Errorf("expected token %q, got %q", tt.wantToken, token)
+			}
+			if !gotExpiry.Equal(tt.wantExpiry) {
+				t.Errorf("expected expiry %v, got %v", tt.wantExpiry, gotExpiry)
+			}
+		})
+	}
+}
+
+func TestTokenCommandExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		expiry   string
+		wantRuns int
+	}{
+		{
+			name:     "without expiry the token is cached",
+			wantRuns: 1,
+		},
+		{
+			name:     "valid token is cached",
+			expiry:   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			wantRuns: 1,
+		},
+		{
+			name:     "token about to expire is renewed",
+			expiry:   time.Now().Add(tokenCommandExpiryDelta / 2).UTC().Format(time.RFC3339),
+			wantRuns: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := filepath.Join(t.TempDir(), "runs")
+			output := `{"token": "token-%s"}`
+			if tt.expiry != "" {
+				output = `{"token": "token-%s", "expiry": "` + tt.expiry + `"}`
+			}
+			script := fmt.Sprintf(`echo run >> %s; printf '%s' "$(wc -l < %s | tr -d ' ')"`, runs, output, runs)
+			command := NewTokenCommand([]string{"sh", "-c", script})
+
+			var token string
+			for range 3 {
+				var err error
+				if token, err = command.Token(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if want := fmt.Sprintf("token-%d", tt.wantRuns); token != want {
+				t.Errorf("expected %s after %d runs, got %s", want, tt.wantRuns, token)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	redacted = "REDACTED"
+	// traceBodyLimit bounds how much of a body ends up in the log.
+	traceBodyLimit = 64 * 1024
+)
+
+// redactedHeaders are never written to the trace.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactedFields are JSON fields holding credentials, matched case-insensitively
+// at any depth of request and response bodies.
+var redactedFields = map[string]bool{
+	"password":                    true,
+	"applicationcredentialsecret": true,
+	"secretaccesskey":             true,
+	"clientsecret":                true,
+	"token":                       true,
+	"kubeconfig":                  true,
+}
+
+// traceTransport logs every request and response with credentials redacted.
+type traceTransport struct {
+	base http.RoundTripper
+	log  *zap.Logger
+}
+
+// NewTraceTransport wraps base so that each request is logged to log with
+// method, URL, status, latency and bodies.
+func NewTraceTransport(base http.RoundTripper, log *zap.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &traceTransport{base: base, log: log}
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.String()),
+		zap.Any("request_headers", redactHeaders(req.Header)),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			raw, _ := io.ReadAll(body)
+			body.Close()
+			fields = append(fields, zap.String("request_body", redactBody(req.URL.Path, raw)))
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	fields = append(fields, zap.Duration("latency", time.Since(start)))
+	if err != nil {
+		t.log.Debug("http request failed", append(fields, zap.Error(err))...)
+		return resp, err
+	}
+
+	raw, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	if readErr != nil {
+		// Hand the error to the client the same way reading the body would have.
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), errReader{readErr}))
+	}
+
+	fields = append(fields,
+		zap.Int("status", resp.StatusCode),
+		zap.Any("response_headers", redactHeaders(resp.Header)),
+		zap.String("response_body", redactBody(req.URL.Path, raw)),
+	)
+	t.log.Debug("http request", fields...)
+
+	return resp, nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range redactedHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// redactBody returns body with all credential fields replaced. Kubeconfig and
+// token endpoints return nothing but credentials, their bodies are dropped entirely.
+func redactBody(urlPath string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	p := strings.ToLower(strings.TrimSuffix(urlPath, "/"))
+	if strings.HasSuffix(p, "kubeconfig") || strings.HasSuffix(p, "token") || strings.Contains(p, "/tokens") {
+		return redacted
+	}
+
+	out := body
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if out, err = json.Marshal(redactValue(v)); err != nil {
+			return redacted
+		}
+	}
+	// Anything else, e.g. an HTML error page of a gateway, is logged as is.
+	if len(out) > traceBodyLimit {
+		return string(out[:traceBodyLimit]) + "...(truncated)"
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if redactedFields[strings.ToLower(k)] {
+				if val != nil && val != "" {
+					v[k] = redacted
+				}
+				continue
+			}
+			v[k] = redactValue(val)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return v
+}
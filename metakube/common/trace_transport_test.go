@@ -0,0 +1,146 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{
+			name: "empty",
+			path: "/api/v1/projects",
+			want: "",
+		},
+		{
+			name: "nested credentials",
+			path: "/api/v2/projects/p/clusters",
+			body: `{"name":"c","spec":{"cloud":{"openstack":{"applicationCredentialSecret":"s3cret","Password":"pw","username":"u"}}}}`,
+			want: `{"name":"c","spec":{"cloud":{"openstack":{"Password":"REDACTED","applicationCredentialSecret":"REDACTED","username":"u"}}}}`,
+		},
+		{
+			name: "credentials in lists",
+			path: "/api/v2/projects/p/clusters",
+			body: `[{"aws":{"secretAccessKey":"key"}},{"token":""}]`,
+			want: `[{"aws":{"secretAccessKey":"REDACTED"}},{"token":""}]`,
+		},
+		{
+			name: "kubeconfig endpoint",
+			path: "/api/v2/projects/p/clusters/c/kubeconfig",
+			body: "apiVersion: v1\nusers: []",
+			want: redacted,
+		},
+		{
+			name: "token endpoint",
+			path: "/api/v1/projects/p/serviceaccounts/s/tokens/",
+			body: `{"name":"t"}`,
+			want: redacted,
+		},
+		{
+			name: "not JSON",
+			path: "/api/v1/projects",
+			body: "<html>Bad Gateway</html>",
+			want: "<html>Bad Gateway</html>",
+		},
+		{
+			name: "truncated",
+			path: "/api/v1/projects",
+			body: strings.Repeat("x", traceBodyLimit+1),
+			want: strings.Repeat("x", traceBodyLimit) + "...(truncated)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody(tt.path, []byte(tt.body)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTraceTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      http.Header
+		requestBody string
+		wantLogged  []string
+		wantRedacts []string
+	}{
+		{
+			name:        "authorization header",
+			header:      http.Header{"Authorization": []string{"Bearer secret-token"}},
+			wantLogged:  []string{"GET", "/api/v1/projects", "200"},
+			wantRedacts: []string{"secret-token"},
+		},
+		{
+			name:        "request body",
+			requestBody: `{"spec":{"password":"secret-password"}}`,
+			wantLogged:  []string{"POST", "/api/v1/projects", "REDACTED"},
+			wantRedacts: []string{"secret-password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				resp := testResponse(http.StatusOK, http.Header{"Set-Cookie": []string{"session=secret-cookie"}})
+				resp.Body = io.NopCloser(strings.NewReader(`{"id":"p","token":"secret-response-token"}`))
+				return resp, nil
+			})
+
+			method, body := http.MethodGet, io.Reader(nil)
+			if tt.requestBody != "" {
+				method, body = http.MethodPost, strings.NewReader(tt.requestBody)
+			}
+			req, err := http.NewRequest(method, "https://metakube.example.com/api/v1/projects", body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			resp, err := NewTraceTransport(base, zap.New(core)).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// The client still reads the whole, unredacted body.
+			if raw, _ := io.ReadAll(resp.Body); !strings.Contains(string(raw), "secret-response-token") {
+				t.Errorf("expected the response body to be passed on, got %s", raw)
+			}
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 log entry, got %d", len(entries))
+			}
+			var logged strings.Builder
+			for k, v := range entries[0].ContextMap() {
+				fmt.Fprintf(&logged, "%s=%v\n", k, v)
+			}
+			for _, want := range tt.wantLogged {
+				if !strings.Contains(logged.String(), want) {
+					t.Errorf("expected %q to be logged, got\n%s", want, logged.String())
+				}
+			}
+			for _, secret := range append(tt.wantRedacts, "secret-cookie", "secret-response-token") {
+				if strings.Contains(logged.String(), secret) {
+					t.Errorf("expected %q to be redacted, got\n%s", secret, logged.String())
+				}
+			}
+		})
+	}
+}
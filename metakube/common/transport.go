@@ -0,0 +1,145 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/mitchellh/go-homedir"
+)
+
+type TransportError struct {
+	Message    string
+	Attributes []string
+}
+
+func (e *TransportError) Error() string {
+	return e.Message
+}
+
+func NewTransportError(message string, attributes ...string) *TransportError {
+	return &TransportError{
+		Message:    message,
+		Attributes: attributes,
+	}
+}
+
+// TLSConfig holds the TLS and proxy settings of the API client.
+type TLSConfig struct {
+	CAFile             string
+	CAPEM              string
+	ClientCert         string
+	ClientKey          string
+	ProxyURL           string
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns an http.Transport for the API client. Without a proxy_url
+// the proxy is taken from the HTTPS_PROXY/NO_PROXY environment as usual.
+func NewTransport(config TLSConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" || config.CAPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if config.CAFile != "" {
+			pem, err := readFileExpanded(config.CAFile)
+			if err != nil {
+				return nil, NewTransportError(fmt.Sprintf("failed to read CA file: %v", err), "ca_file")
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, NewTransportError("no PEM encoded certificates found in CA file", "ca_file")
+			}
+		}
+		if config.CAPEM != "" && !pool.AppendCertsFromPEM([]byte(config.CAPEM)) {
+			return nil, NewTransportError("no PEM encoded certificates found in ca_pem", "ca_pem")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCert != "" || config.ClientKey != "" {
+		if config.ClientCert == "" || config.ClientKey == "" {
+			return nil, NewTransportError("client_cert and client_key must be set together", "client_cert", "client_key")
+		}
+		certPEM, err := pemOrFile(config.ClientCert)
+		if err != nil {
+			return nil, NewTransportError(fmt.Sprintf("failed to read client certificate: %v", err), "client_cert")
+		}
+		keyPEM, err := pemOrFile(config.ClientKey)
+		if err != nil {
+			return nil, NewTransportError(fmt.Sprintf("failed to read client key: %v", err), "client_key")
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, NewTransportError(fmt.Sprintf("invalid client certificate or key: %v", err), "client_cert", "client_key")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, NewTransportError(fmt.Sprintf("invalid proxy URL %q", config.ProxyURL), "proxy_url")
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// pemOrFile returns value itself if it is PEM encoded, otherwise the content of the file it points to.
+func pemOrFile(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return readFileExpanded(value)
+}
+
+func readFileExpanded(p string) ([]byte, error) {
+	expandedPath, err := homedir.Expand(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(expandedPath)
+}
+
+func TransportToFrameworkDiagnostics(err error) fwdiag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var diags fwdiag.Diagnostics
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		for _, attr := range transportErr.Attributes {
+			diags.AddAttributeError(
+				path.Root(attr),
+				"Client Configuration Error",
+				transportErr.Message,
+			)
+		}
+
+		if len(transportErr.Attributes) == 0 {
+			diags.AddError("Client Configuration Error", transportErr.Message)
+		}
+	} else {
+		diags.AddError("Client Configuration Error", err.Error())
+	}
+
+	return diags
+}
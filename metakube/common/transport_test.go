@@ -0,0 +1,202 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testCertificate returns a self-signed PEM encoded certificate and its key.
+func testCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "metakube-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestNewTransport(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	_, otherKeyPEM := testCertificate(t)
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return p
+	}
+	certFile := writeFile("cert.pem", certPEM)
+	keyFile := writeFile("key.pem", keyPEM)
+	notPEMFile := writeFile("not.pem", "not a certificate")
+	missingFile := filepath.Join(dir, "missing.pem")
+
+	tests := []struct {
+		name           string
+		config         TLSConfig
+		wantAttributes []string
+		wantRootCAs    bool
+		wantClientCert bool
+		wantProxy      string
+	}{
+		{
+			name:   "defaults",
+			config: TLSConfig{},
+		},
+		{
+			name:        "CA file",
+			config:      TLSConfig{CAFile: certFile},
+			wantRootCAs: true,
+		},
+		{
+			name:        "CA PEM",
+			config:      TLSConfig{CAPEM: certPEM},
+			wantRootCAs: true,
+		},
+		{
+			name:           "unreadable CA file",
+			config:         TLSConfig{CAFile: missingFile},
+			wantAttributes: []string{"ca_file"},
+		},
+		{
+			name:           "CA file without certificates",
+			config:         TLSConfig{CAFile: notPEMFile},
+			wantAttributes: []string{"ca_file"},
+		},
+		{
+			name:           "invalid CA PEM",
+			config:         TLSConfig{CAPEM: "not a certificate"},
+			wantAttributes: []string{"ca_pem"},
+		},
+		{
+			name:           "client certificate and key as PEM",
+			config:         TLSConfig{ClientCert: certPEM, ClientKey: keyPEM},
+			wantClientCert: true,
+		},
+		{
+			name:           "client certificate and key as files",
+			config:         TLSConfig{ClientCert: certFile, ClientKey: keyFile},
+			wantClientCert: true,
+		},
+		{
+			name:           "client certificate without key",
+			config:         TLSConfig{ClientCert: certPEM},
+			wantAttributes: []string{"client_cert", "client_key"},
+		},
+		{
+			name:           "client key without certificate",
+			config:         TLSConfig{ClientKey: keyPEM},
+			wantAttributes: []string{"client_cert", "client_key"},
+		},
+		{
+			name:           "unreadable client certificate",
+			config:         TLSConfig{ClientCert: missingFile, ClientKey: keyPEM},
+			wantAttributes: []string{"client_cert"},
+		},
+		{
+			name:           "unreadable client key",
+			config:         TLSConfig{ClientCert: certPEM, ClientKey: missingFile},
+			wantAttributes: []string{"client_key"},
+		},
+		{
+			name:           "mismatching client certificate and key",
+			config:         TLSConfig{ClientCert: certPEM, ClientKey: otherKeyPEM},
+			wantAttributes: []string{"client_cert", "client_key"},
+		},
+		{
+			name:      "proxy URL",
+			config:    TLSConfig{ProxyURL: "http://proxy.example.com:3128"},
+			wantProxy: "http://proxy.example.com:3128",
+		},
+		{
+			name:           "proxy URL without scheme",
+			config:         TLSConfig{ProxyURL: "proxy.example.com:3128"},
+			wantAttributes: []string{"proxy_url"},
+		},
+		{
+			name:           "invalid proxy URL",
+			config:         TLSConfig{ProxyURL: "http://proxy example.com"},
+			wantAttributes: []string{"proxy_url"},
+		},
+		{
+			name:   "insecure skip verify",
+			config: TLSConfig{InsecureSkipVerify: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.config)
+			if tt.wantAttributes != nil {
+				var transportErr *TransportError
+				if !errors.As(err, &transportErr) {
+					t.Fatalf("expected a TransportError, got %v", err)
+				}
+				if diff := cmp.Diff(tt.wantAttributes, transportErr.Attributes); diff != "" {
+					t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+				}
+				if diags := TransportToFrameworkDiagnostics(err); len(diags) != len(tt.wantAttributes) {
+					t.Errorf("expected one diagnostic per attribute, got %v", diags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tlsConfig := transport.TLSClientConfig
+			if tlsConfig.InsecureSkipVerify != tt.config.InsecureSkipVerify {
+				t.Errorf("expected InsecureSkipVerify %v, got %v", tt.config.InsecureSkipVerify, tlsConfig.InsecureSkipVerify)
+			}
+			if got := tlsConfig.RootCAs != nil; got != tt.wantRootCAs {
+				t.Errorf("expected custom root CAs %v, got %v", tt.wantRootCAs, got)
+			}
+			if got := len(tlsConfig.Certificates) == 1; got != tt.wantClientCert {
+				t.Errorf("expected a client certificate %v, got %v", tt.wantClientCert, got)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "https://metakube.example.com", nil)
+			proxy, err := transport.Proxy(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantProxy != "" && (proxy == nil || proxy.String() != tt.wantProxy) {
+				t.Errorf("expected proxy %s, got %v", tt.wantProxy, proxy)
+			}
+		})
+	}
+}
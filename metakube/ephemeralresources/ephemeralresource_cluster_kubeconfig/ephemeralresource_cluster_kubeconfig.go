@@ -0,0 +1,93 @@
+package ephemeralresource_cluster_kubeconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = &clusterKubeconfigEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &clusterKubeconfigEphemeralResource{}
+)
+
+func NewClusterKubeconfig() ephemeral.EphemeralResource {
+	return &clusterKubeconfigEphemeralResource{}
+}
+
+type clusterKubeconfigEphemeralResource struct {
+	meta *common.MetaKubeProviderMeta
+}
+
+func (e *clusterKubeconfigEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster_kubeconfig"
+}
+
+func (e *clusterKubeconfigEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = ClusterKubeconfigSchema()
+}
+
+func (e *clusterKubeconfigEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	meta, ok := req.ProviderData.(*common.MetaKubeProviderMeta)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Ephemeral Resource Configure Type",
+			fmt.Sprintf("Expected *common.MetaKubeProviderMeta, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	e.meta = meta
+}
+
+func (e *clusterKubeconfigEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	ctx = e.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+
+	var data clusterKubeconfigModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	clusterID := data.ClusterID.ValueString()
+	projectID := data.ProjectID.ValueString()
+	if projectID == "" {
+		projectID = e.meta.DefaultProjectID
+	}
+	if projectID == "" {
+		var err error
+		projectID, err = common.MetakubeResourceClusterFindProjectID(ctx, clusterID, e.meta)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to find project", err.Error())
+			return
+		}
+		if projectID == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("cluster_id"), "Cluster not found", fmt.Sprintf("Could not find the project of cluster '%s'", clusterID))
+			return
+		}
+	}
+
+	kubeconfigType := data.Type.ValueString()
+	if kubeconfigType == "" {
+		kubeconfigType = common.KubeconfigAdmin
+	}
+
+	conf, err := common.MetakubeGetKubeconfig(ctx, e.meta, projectID, clusterID, kubeconfigType)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to get kubeconfig", err.Error())
+		return
+	}
+
+	data.ProjectID = types.StringValue(projectID)
+	data.Type = types.StringValue(kubeconfigType)
+	data.KubeConfig = types.StringValue(conf)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
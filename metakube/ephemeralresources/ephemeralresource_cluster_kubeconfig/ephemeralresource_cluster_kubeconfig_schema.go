@@ -0,0 +1,49 @@
+package ephemeralresource_cluster_kubeconfig
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+func ClusterKubeconfigSchema() schema.Schema {
+	return schema.Schema{
+		Description: "Kubeconfig of a cluster, which is never stored in the plan or state",
+		Attributes: map[string]schema.Attribute{
+			"project_id": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Project of the cluster, defaults to the project of the provider profile or is looked up by cluster_id",
+			},
+			"cluster_id": schema.StringAttribute{
+				Required:    true,
+				Description: "Cluster identifier",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Kubeconfig variant: admin, oidc or kubelogin. Defaults to admin. oidc and kubelogin require syseleven_auth on the cluster",
+				Validators: []validator.String{
+					stringvalidator.OneOf(common.KubeconfigTypes...),
+				},
+			},
+			"kube_config": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Kubeconfig of the requested type",
+			},
+		},
+	}
+}
+
+type clusterKubeconfigModel struct {
+	ProjectID  types.String `tfsdk:"project_id"`
+	ClusterID  types.String `tfsdk:"cluster_id"`
+	Type       types.String `tfsdk:"type"`
+	KubeConfig types.String `tfsdk:"kube_config"`
+}
@@ -0,0 +1,229 @@
+package function_kubeconfig_decode
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+var _ function.Function = &kubeconfigDecodeFunction{}
+
+func NewKubeconfigDecode() function.Function {
+	return &kubeconfigDecodeFunction{}
+}
+
+type kubeconfigDecodeFunction struct{}
+
+type kubeconfigModel struct {
+	Context              types.String `tfsdk:"context"`
+	Namespace            types.String `tfsdk:"namespace"`
+	Host                 types.String `tfsdk:"host"`
+	ClusterCACertificate types.String `tfsdk:"cluster_ca_certificate"`
+	ClientCertificate    types.String `tfsdk:"client_certificate"`
+	ClientKey            types.String `tfsdk:"client_key"`
+	Token                types.String `tfsdk:"token"`
+	Exec                 types.Object `tfsdk:"exec"`
+	AuthProvider         types.Object `tfsdk:"auth_provider"`
+}
+
+type execModel struct {
+	APIVersion types.String `tfsdk:"api_version"`
+	Command    types.String `tfsdk:"command"`
+	Args       types.List   `tfsdk:"args"`
+	Env        types.Map    `tfsdk:"env"`
+}
+
+type authProviderModel struct {
+	Name   types.String `tfsdk:"name"`
+	Config types.Map    `tfsdk:"config"`
+}
+
+var execAttributeTypes = map[string]attr.Type{
+	"api_version": types.StringType,
+	"command":     types.StringType,
+	"args":        types.ListType{ElemType: types.StringType},
+	"env":         types.MapType{ElemType: types.StringType},
+}
+
+var authProviderAttributeTypes = map[string]attr.Type{
+	"name":   types.StringType,
+	"config": types.MapType{ElemType: types.StringType},
+}
+
+var kubeconfigAttributeTypes = map[string]attr.Type{
+	"context":                types.StringType,
+	"namespace":              types.StringType,
+	"host":                   types.StringType,
+	"cluster_ca_certificate": types.StringType,
+	"client_certificate":     types.StringType,
+	"client_key":             types.StringType,
+	"token":                  types.StringType,
+	"exec":                   types.ObjectType{AttrTypes: execAttributeTypes},
+	"auth_provider":          types.ObjectType{AttrTypes: authProviderAttributeTypes},
+}
+
+func (f *kubeconfigDecodeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "kubeconfig_decode"
+}
+
+func (f *kubeconfigDecodeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Decode the current context of a kubeconfig",
+		Description: "Returns the server, credentials and exec settings of the current context of a kubeconfig, " +
+			"in the form the kubernetes and helm providers expect them. Certificates and keys are PEM encoded, " +
+			"settings missing from the kubeconfig are null.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "kubeconfig",
+				Description: "Kubeconfig, e.g. kube_config of metakube_cluster",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: kubeconfigAttributeTypes,
+		},
+	}
+}
+
+func (f *kubeconfigDecodeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var kubeconfig string
+	resp.Error = req.Arguments.Get(ctx, &kubeconfig)
+	if resp.Error != nil {
+		return
+	}
+
+	k, err := common.ParseKubeconfig(kubeconfig)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	model, err := decodeKubeconfig(ctx, k)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, model)
+}
+
+func decodeKubeconfig(ctx context.Context, k *common.Kubeconfig) (*kubeconfigModel, error) {
+	c, err := k.CurrentNamedContext()
+	if err != nil {
+		return nil, err
+	}
+
+	model := &kubeconfigModel{
+		Context:      types.StringValue(c.Name),
+		Namespace:    optionalString(c.Context.Namespace),
+		Exec:         types.ObjectNull(execAttributeTypes),
+		AuthProvider: types.ObjectNull(authProviderAttributeTypes),
+	}
+
+	cluster := k.NamedCluster(c.Context.Cluster)
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %q of context %q not found in kubeconfig", c.Context.Cluster, c.Name)
+	}
+	model.Host = optionalString(cluster.Cluster.Server)
+	if model.ClusterCACertificate, err = base64String(cluster.Cluster.CertificateAuthorityData); err != nil {
+		return nil, fmt.Errorf("certificate-authority-data of cluster %q: %v", cluster.Name, err)
+	}
+
+	user := k.NamedUser(c.Context.User)
+	if user == nil {
+		return nil, fmt.Errorf("user %q of context %q not found in kubeconfig", c.Context.User, c.Name)
+	}
+	if model.ClientCertificate, err = base64String(user.User.ClientCertificateData); err != nil {
+		return nil, fmt.Errorf("client-certificate-data of user %q: %v", user.Name, err)
+	}
+	if model.ClientKey, err = base64String(user.User.ClientKeyData); err != nil {
+		return nil, fmt.Errorf("client-key-data of user %q: %v", user.Name, err)
+	}
+	model.Token = optionalString(user.User.Token)
+
+	if exec := user.User.Exec; exec != nil {
+		if model.Exec, err = execValue(ctx, exec); err != nil {
+			return nil, fmt.Errorf("exec of user %q: %v", user.Name, err)
+		}
+	}
+	if ap := user.User.AuthProvider; ap != nil {
+		if model.AuthProvider, err = authProviderValue(ctx, ap); err != nil {
+			return nil, fmt.Errorf("auth-provider of user %q: %v", user.Name, err)
+		}
+	}
+
+	return model, nil
+}
+
+func execValue(ctx context.Context, exec *common.KubeconfigExec) (types.Object, error) {
+	args := exec.Args
+	if args == nil {
+		args = []string{}
+	}
+	env := make(map[string]string, len(exec.Env))
+	for _, e := range exec.Env {
+		env[e.Name] = e.Value
+	}
+
+	m := execModel{
+		APIVersion: optionalString(exec.APIVersion),
+		Command:    types.StringValue(exec.Command),
+	}
+	var diags diag.Diagnostics
+	m.Args, diags = types.ListValueFrom(ctx, types.StringType, args)
+	if diags.HasError() {
+		return types.ObjectNull(execAttributeTypes), errors.New("invalid args")
+	}
+	m.Env, diags = types.MapValueFrom(ctx, types.StringType, env)
+	if diags.HasError() {
+		return types.ObjectNull(execAttributeTypes), errors.New("invalid env")
+	}
+	v, diags := types.ObjectValueFrom(ctx, execAttributeTypes, m)
+	if diags.HasError() {
+		return types.ObjectNull(execAttributeTypes), errors.New("invalid exec settings")
+	}
+	return v, nil
+}
+
+func authProviderValue(ctx context.Context, ap *common.KubeconfigAuthProvider) (types.Object, error) {
+	config := ap.Config
+	if config == nil {
+		config = map[string]string{}
+	}
+
+	m := authProviderModel{Name: types.StringValue(ap.Name)}
+	var diags diag.Diagnostics
+	m.Config, diags = types.MapValueFrom(ctx, types.StringType, config)
+	if diags.HasError() {
+		return types.ObjectNull(authProviderAttributeTypes), errors.New("invalid config")
+	}
+	v, diags := types.ObjectValueFrom(ctx, authProviderAttributeTypes, m)
+	if diags.HasError() {
+		return types.ObjectNull(authProviderAttributeTypes), errors.New("invalid auth-provider settings")
+	}
+	return v, nil
+}
+
+func optionalString(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
+
+// base64String decodes the base64 encoded *-data fields of a kubeconfig.
+func base64String(s string) (types.String, error) {
+	if s == "" {
+		return types.StringNull(), nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	return types.StringValue(string(b)), nil
+}
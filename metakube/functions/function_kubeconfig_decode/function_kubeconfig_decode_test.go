@@ -0,0 +1,146 @@
+package function_kubeconfig_decode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: abc123
+clusters:
+- name: abc123
+  cluster:
+    server: https://abc123.dbl1.metakube.syseleven.de:6443
+    certificate-authority-data: Q0EgUEVN
+contexts:
+- name: abc123
+  context:
+    cluster: abc123
+    user: default
+users:
+- name: default
+  user:
+    token: secret
+`
+
+const testExecKubeconfig = `apiVersion: v1
+kind: Config
+current-context: oidc
+clusters:
+- name: abc123
+  cluster:
+    server: https://abc123.dbl1.metakube.syseleven.de:6443
+contexts:
+- name: admin
+  context:
+    cluster: abc123
+    user: admin
+- name: oidc
+  context:
+    cluster: abc123
+    user: oidc
+    namespace: apps
+users:
+- name: admin
+  user:
+    token: secret
+- name: oidc
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubectl
+      args:
+      - oidc-login
+      - get-token
+      env:
+      - name: FOO
+        value: bar
+`
+
+func TestKubeconfigDecode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("token", func(t *testing.T) {
+		got := runDecode(t, testKubeconfig)
+		expectString(t, got, "context", "abc123")
+		expectString(t, got, "host", "https://abc123.dbl1.metakube.syseleven.de:6443")
+		expectString(t, got, "cluster_ca_certificate", "CA PEM")
+		expectString(t, got, "token", "secret")
+		for _, name := range []string{"namespace", "client_certificate", "client_key", "exec", "auth_provider"} {
+			if !got.Attributes()[name].IsNull() {
+				t.Errorf("expected %s to be null, got %s", name, got.Attributes()[name])
+			}
+		}
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		got := runDecode(t, testExecKubeconfig)
+		expectString(t, got, "context", "oidc")
+		expectString(t, got, "namespace", "apps")
+		if !got.Attributes()["token"].IsNull() {
+			t.Errorf("expected token of the current context's user to be null")
+		}
+
+		var exec execModel
+		if diags := got.Attributes()["exec"].(types.Object).As(ctx, &exec, basetypes.ObjectAsOptions{}); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		if exec.Command.ValueString() != "kubectl" {
+			t.Errorf("expected command kubectl, got %s", exec.Command)
+		}
+		var args []string
+		exec.Args.ElementsAs(ctx, &args, false)
+		if len(args) != 2 || args[0] != "oidc-login" {
+			t.Errorf("unexpected args %v", args)
+		}
+		if v := exec.Env.Elements()["FOO"]; v == nil || v.(types.String).ValueString() != "bar" {
+			t.Errorf("unexpected env %v", exec.Env)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, kubeconfig := range []string{
+			"",
+			"not: [yaml",
+			"current-context: missing\ncontexts:\n- name: other\n",
+			"contexts:\n- name: a\n  context:\n    cluster: missing\n",
+		} {
+			resp := decode(kubeconfig)
+			if resp.Error == nil {
+				t.Errorf("expected an error for %q", kubeconfig)
+			}
+		}
+	})
+}
+
+func decode(kubeconfig string) *function.RunResponse {
+	resp := &function.RunResponse{
+		Result: function.NewResultData(types.ObjectUnknown(kubeconfigAttributeTypes)),
+	}
+	NewKubeconfigDecode().Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(kubeconfig)}),
+	}, resp)
+	return resp
+}
+
+func runDecode(t *testing.T, kubeconfig string) types.Object {
+	t.Helper()
+	resp := decode(kubeconfig)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	return resp.Result.Value().(types.Object)
+}
+
+func expectString(t *testing.T, o types.Object, name, expected string) {
+	t.Helper()
+	if got := o.Attributes()[name].(types.String).ValueString(); got != expected {
+		t.Errorf("expected %s %q, got %q", name, expected, got)
+	}
+}
@@ -0,0 +1,108 @@
+package function_kubeconfig_set_context
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+var _ function.Function = &kubeconfigSetContextFunction{}
+
+func NewKubeconfigSetContext() function.Function {
+	return &kubeconfigSetContextFunction{}
+}
+
+type kubeconfigSetContextFunction struct{}
+
+func (f *kubeconfigSetContextFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "kubeconfig_set_context"
+}
+
+func (f *kubeconfigSetContextFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Rename the current context of a kubeconfig",
+		Description: "Returns the kubeconfig with its current context, and the cluster and user the context refers to, " +
+			"renamed to name, so that kubeconfigs of several clusters can be merged without conflicts. " +
+			"The namespace of the context is set to namespace unless it is null, an empty string removes it.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "kubeconfig",
+				Description: "Kubeconfig, e.g. kube_config of metakube_cluster",
+			},
+			function.StringParameter{
+				Name:        "name",
+				Description: "New name of the context",
+			},
+			function.StringParameter{
+				Name:           "namespace",
+				Description:    "Namespace of the context, null keeps the namespace of the kubeconfig",
+				AllowNullValue: true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *kubeconfigSetContextFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var kubeconfig, name string
+	var namespace types.String
+	resp.Error = req.Arguments.Get(ctx, &kubeconfig, &name, &namespace)
+	if resp.Error != nil {
+		return
+	}
+	if name == "" {
+		resp.Error = function.NewArgumentFuncError(1, "name must not be empty")
+		return
+	}
+
+	k, err := common.ParseKubeconfig(kubeconfig)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	if err := setContext(k, name, namespace); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	result, err := k.String()
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, result)
+}
+
+// setContext renames the current context along with its cluster and user.
+// Other contexts referring to the same cluster or user are updated as well.
+func setContext(k *common.Kubeconfig, name string, namespace types.String) error {
+	c, err := k.CurrentNamedContext()
+	if err != nil {
+		return err
+	}
+
+	clusterName, userName := c.Context.Cluster, c.Context.User
+	if cluster := k.NamedCluster(clusterName); cluster != nil {
+		cluster.Name = name
+	}
+	if user := k.NamedUser(userName); user != nil {
+		user.Name = name
+	}
+	for _, other := range k.Contexts {
+		if other.Context.Cluster == clusterName {
+			other.Context.Cluster = name
+		}
+		if other.Context.User == userName {
+			other.Context.User = name
+		}
+	}
+
+	c.Name = name
+	if !namespace.IsNull() {
+		c.Context.Namespace = namespace.ValueString()
+	}
+	k.CurrentContext = name
+	return nil
+}
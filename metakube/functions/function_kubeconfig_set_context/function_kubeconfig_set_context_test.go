@@ -0,0 +1,109 @@
+package function_kubeconfig_set_context
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+preferences: {}
+current-context: abc123
+clusters:
+- name: abc123
+  cluster:
+    server: https://abc123.dbl1.metakube.syseleven.de:6443
+    certificate-authority-data: Q0EgUEVN
+contexts:
+- name: abc123
+  context:
+    cluster: abc123
+    user: default
+    namespace: kube-system
+users:
+- name: default
+  user:
+    token: secret
+`
+
+func TestKubeconfigSetContext(t *testing.T) {
+	cases := []struct {
+		name              string
+		namespace         types.String
+		expectedNamespace string
+	}{
+		{
+			name:              "keep namespace",
+			namespace:         types.StringNull(),
+			expectedNamespace: "kube-system",
+		},
+		{
+			name:              "set namespace",
+			namespace:         types.StringValue("apps"),
+			expectedNamespace: "apps",
+		},
+		{
+			name:              "remove namespace",
+			namespace:         types.StringValue(""),
+			expectedNamespace: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := run(testKubeconfig, "prod", tc.namespace)
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+
+			k, err := common.ParseKubeconfig(resp.Result.Value().(types.String).ValueString())
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := k.CurrentNamedContext()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Name != "prod" || c.Context.Cluster != "prod" || c.Context.User != "prod" {
+				t.Errorf("expected context, cluster and user to be renamed, got %+v", c)
+			}
+			if c.Context.Namespace != tc.expectedNamespace {
+				t.Errorf("expected namespace %q, got %q", tc.expectedNamespace, c.Context.Namespace)
+			}
+			if cluster := k.NamedCluster("prod"); cluster == nil || cluster.Cluster.CertificateAuthorityData != "Q0EgUEVN" {
+				t.Errorf("expected renamed cluster, got %+v", cluster)
+			}
+			if user := k.NamedUser("prod"); user == nil || user.User.Token != "secret" {
+				t.Errorf("expected renamed user, got %+v", user)
+			}
+			if _, ok := k.Extra["preferences"]; !ok {
+				t.Errorf("expected preferences to be kept")
+			}
+		})
+	}
+
+	t.Run("empty name", func(t *testing.T) {
+		if resp := run(testKubeconfig, "", types.StringNull()); resp.Error == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+func run(kubeconfig, name string, namespace types.String) *function.RunResponse {
+	resp := &function.RunResponse{
+		Result: function.NewResultData(types.StringUnknown()),
+	}
+	NewKubeconfigSetContext().Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue(kubeconfig),
+			types.StringValue(name),
+			namespace,
+		}),
+	}, resp)
+	return resp
+}
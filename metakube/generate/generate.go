@@ -0,0 +1,340 @@
+// Package generate implements the generate subcommand of the provider binary.
+// It writes Terraform configuration with import blocks for the objects of
+// existing MetaKube projects.
+package generate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/syseleven/go-metakube/client/project"
+	"github.com/syseleven/terraform-provider-metakube/metakube"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_cluster"
+	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_cluster_role_binding"
+	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_maintenance_cronjob"
+	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_node_deployment"
+	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_role_binding"
+	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_sshkey"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const providerTypeName = "metakube"
+
+// Main runs the generate subcommand with the arguments following its name.
+// The provider is configured from the environment and the profile, as if the
+// provider block was empty.
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: terraform-provider-metakube generate [-project-id ID] [-out FILE]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Writes resources and import blocks for the clusters, node deployments, role bindings,")
+		fmt.Fprintln(stderr, "maintenance cron jobs and SSH keys of a MetaKube project. The provider settings are")
+		fmt.Fprintln(stderr, "read from the METAKUBE_* environment variables and the profile.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	projectID := flags.String("project-id", "", "project to generate configuration for, defaults to the project of the profile, otherwise all projects")
+	out := flags.String("out", "", "file to write the configuration to, defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	g, err := newGenerator(ctx, stderr)
+	if err != nil {
+		return err
+	}
+
+	projectIDs, err := common.MetakubeListProjectIDs(ctx, *projectID, g.meta)
+	if err != nil {
+		return err
+	}
+
+	f := hclwrite.NewFile()
+	for _, id := range projectIDs {
+		if err := g.writeProject(ctx, f.Body(), id); err != nil {
+			return err
+		}
+	}
+
+	content := append(bytes.TrimRight(f.Bytes(), "\n"), '\n')
+	if *out == "" {
+		_, err = stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(*out, content, 0o644)
+}
+
+type generator struct {
+	meta    *common.MetaKubeProviderMeta
+	schemas map[string]*tfprotov6.Schema
+	names   map[string]bool
+	stderr  io.Writer
+}
+
+func newGenerator(ctx context.Context, stderr io.Writer) (*generator, error) {
+	p := metakube.NewProvider()
+
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    nullAttributes(schemaResp.Schema.Type().TerraformType(ctx)),
+	}
+
+	var configureResp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{Config: config}, &configureResp)
+	if err := diagnosticsError(configureResp.Diagnostics, stderr); err != nil {
+		return nil, err
+	}
+	meta, ok := configureResp.ResourceData.(*common.MetaKubeProviderMeta)
+	if !ok {
+		return nil, fmt.Errorf("unexpected provider data %T", configureResp.ResourceData)
+	}
+	// The kubeconfigs are computed, they never appear in the configuration.
+	meta.SkipKubeconfigs = true
+
+	server, err := providerserver.NewProtocol6WithError(metakube.NewProvider())()
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &generator{
+		meta:    meta,
+		schemas: schemas.ResourceSchemas,
+		names:   map[string]bool{},
+		stderr:  stderr,
+	}, nil
+}
+
+func (g *generator) writeProject(ctx context.Context, body *hclwrite.Body, projectID string) error {
+	keys, err := g.meta.Client.Project.ListSSHKeys(project.NewListSSHKeysParams().WithContext(ctx).WithProjectID(projectID), g.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list SSH keys of project '%s': %s", projectID, common.StringifyResponseError(err))
+	}
+	for _, key := range keys.Payload {
+		if _, err := g.writeResource(ctx, body, resource_sshkey.NewSSHKey, projectID+":"+key.ID, key.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	clusters, err := g.meta.Client.Project.ListClustersV2(project.NewListClustersV2Params().WithContext(ctx).WithProjectID(projectID), g.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list clusters of project '%s': %s", projectID, common.StringifyResponseError(err))
+	}
+	for _, cluster := range clusters.Payload {
+		name, err := g.writeResource(ctx, body, resource_cluster.NewClusterResource, projectID+":"+cluster.ID, cluster.Name, nil)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		if err := g.writeClusterResources(ctx, body, projectID, cluster.ID, cluster.Name, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeClusterResources writes the objects inside a cluster, referencing the
+// cluster resource by name.
+func (g *generator) writeClusterResources(ctx context.Context, body *hclwrite.Body, projectID, clusterID, clusterName, name string) error {
+	overrides := map[string]hclwrite.Tokens{
+		"cluster_id": hclwrite.TokensForTraversal(hcl.Traversal{
+			hcl.TraverseRoot{Name: providerTypeName + "_cluster"},
+			hcl.TraverseAttr{Name: name},
+			hcl.TraverseAttr{Name: "id"},
+		}),
+	}
+	importPrefix := projectID + ":" + clusterID + ":"
+
+	nds, err := g.meta.Client.Project.ListMachineDeployments(project.NewListMachineDeploymentsParams().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID), g.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list node deployments of cluster '%s': %s", clusterID, common.StringifyResponseError(err))
+	}
+	for _, nd := range nds.Payload {
+		if _, err := g.writeResource(ctx, body, resource_node_deployment.NewNodeDeployment, importPrefix+nd.ID, clusterName+"_"+nd.Name, overrides); err != nil {
+			return err
+		}
+	}
+
+	crbs, err := g.meta.Client.Project.ListClusterRoleBindingV2(project.NewListClusterRoleBindingV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID), g.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list cluster role bindings of cluster '%s': %s", clusterID, common.StringifyResponseError(err))
+	}
+	seen := map[string]bool{}
+	for _, crb := range crbs.Payload {
+		if len(crb.Subjects) == 0 || seen[crb.RoleRefName] {
+			continue
+		}
+		seen[crb.RoleRefName] = true
+		if _, err := g.writeResource(ctx, body, resource_cluster_role_binding.NewClusterRoleBinding, importPrefix+crb.RoleRefName, clusterName+"_"+crb.RoleRefName, overrides); err != nil {
+			return err
+		}
+	}
+
+	rbs, err := g.meta.Client.Project.ListRoleBindingV2(project.NewListRoleBindingV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID), g.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list role bindings of cluster '%s': %s", clusterID, common.StringifyResponseError(err))
+	}
+	seen = map[string]bool{}
+	for _, rb := range rbs.Payload {
+		id := rb.Namespace + ":" + rb.RoleRefName
+		if len(rb.Subjects) == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := g.writeResource(ctx, body, resource_role_binding.NewRoleBinding, importPrefix+id, clusterName+"_"+rb.Namespace+"_"+rb.RoleRefName, overrides); err != nil {
+			return err
+		}
+	}
+
+	jobs, err := g.meta.Client.Project.ListMaintenanceCronJobs(project.NewListMaintenanceCronJobsParams().WithContext(ctx).WithProjectID(projectID).WithClusterID(clusterID), g.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list maintenance cron jobs of cluster '%s': %s", clusterID, common.StringifyResponseError(err))
+	}
+	for _, job := range jobs.Payload {
+		if _, err := g.writeResource(ctx, body, resource_maintenance_cronjob.NewMaintenanceCronJob, importPrefix+job.ID, clusterName+"_"+job.Name, overrides); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeResource writes an import block and the resource for the object with
+// the given import ID. It returns the resource name, which is empty when the
+// object could not be read.
+func (g *generator) writeResource(ctx context.Context, body *hclwrite.Body, newResource func() resource.Resource, importID, objectName string, overrides map[string]hclwrite.Tokens) (string, error) {
+	r := newResource()
+	var metadataResp resource.MetadataResponse
+	r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: providerTypeName}, &metadataResp)
+	typeName := metadataResp.TypeName
+
+	value, err := g.read(ctx, r, importID)
+	if err != nil {
+		fmt.Fprintf(g.stderr, "Skipping %s '%s': %v\n", typeName, importID, err)
+		return "", nil
+	}
+
+	name := resourceName(objectName)
+	for i := 2; g.names[typeName+"."+name]; i++ {
+		name = fmt.Sprintf("%s_%d", resourceName(objectName), i)
+	}
+	g.names[typeName+"."+name] = true
+
+	address := hcl.Traversal{hcl.TraverseRoot{Name: typeName}, hcl.TraverseAttr{Name: name}}
+	importBlock := body.AppendNewBlock("import", nil)
+	importBlock.Body().SetAttributeTraversal("to", address)
+	importBlock.Body().SetAttributeValue("id", cty.StringVal(importID))
+	body.AppendNewline()
+
+	resourceBlock := body.AppendNewBlock("resource", []string{typeName, name})
+	if err := writeBody(resourceBlock.Body(), g.schemas[typeName].Block, value, overrides); err != nil {
+		return "", fmt.Errorf("write %s '%s': %w", typeName, importID, err)
+	}
+	body.AppendNewline()
+
+	return name, nil
+}
+
+// read imports and reads an object the way terraform import does, so the
+// generated configuration matches the state Terraform stores for it.
+func (g *generator) read(ctx context.Context, r resource.Resource, importID string) (tftypes.Value, error) {
+	if rc, ok := r.(resource.ResourceWithConfigure); ok {
+		var configureResp resource.ConfigureResponse
+		rc.Configure(ctx, resource.ConfigureRequest{ProviderData: g.meta}, &configureResp)
+		if err := diagnosticsError(configureResp.Diagnostics, g.stderr); err != nil {
+			return tftypes.Value{}, err
+		}
+	}
+	ri, ok := r.(resource.ResourceWithImportState)
+	if !ok {
+		return tftypes.Value{}, errors.New("import is not supported")
+	}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	var identity *tfsdk.ResourceIdentity
+	if rid, ok := r.(resource.ResourceWithIdentity); ok {
+		var identityResp resource.IdentitySchemaResponse
+		rid.IdentitySchema(ctx, resource.IdentitySchemaRequest{}, &identityResp)
+		identity = &tfsdk.ResourceIdentity{
+			Schema: identityResp.IdentitySchema,
+			Raw:    tftypes.NewValue(identityResp.IdentitySchema.Type().TerraformType(ctx), nil),
+		}
+	}
+
+	importResp := resource.ImportStateResponse{State: state, Identity: identity}
+	ri.ImportState(ctx, resource.ImportStateRequest{ID: importID, Identity: identity}, &importResp)
+	if err := diagnosticsError(importResp.Diagnostics, g.stderr); err != nil {
+		return tftypes.Value{}, err
+	}
+
+	readResp := resource.ReadResponse{
+		State:    tfsdk.State{Schema: importResp.State.Schema, Raw: importResp.State.Raw.Copy()},
+		Identity: importResp.Identity,
+	}
+	r.Read(ctx, resource.ReadRequest{State: importResp.State, Identity: importResp.Identity}, &readResp)
+	if err := diagnosticsError(readResp.Diagnostics, g.stderr); err != nil {
+		return tftypes.Value{}, err
+	}
+	if readResp.State.Raw.IsNull() {
+		return tftypes.Value{}, errors.New("not found")
+	}
+
+	return readResp.State.Raw, nil
+}
+
+// nullAttributes returns an object of the given type with all attributes null,
+// like an empty configuration block.
+func nullAttributes(typ tftypes.Type) tftypes.Value {
+	object, ok := typ.(tftypes.Object)
+	if !ok {
+		return tftypes.NewValue(typ, nil)
+	}
+	values := make(map[string]tftypes.Value, len(object.AttributeTypes))
+	for name, t := range object.AttributeTypes {
+		values[name] = tftypes.NewValue(t, nil)
+	}
+	return tftypes.NewValue(object, values)
+}
+
+// diagnosticsError prints warnings and returns the errors, if any, as one error.
+func diagnosticsError(diags diag.Diagnostics, stderr io.Writer) error {
+	var errs []error
+	for _, d := range diags {
+		if d.Severity() == diag.SeverityError {
+			errs = append(errs, fmt.Errorf("%s: %s", d.Summary(), d.Detail()))
+		} else {
+			fmt.Fprintf(stderr, "Warning: %s: %s\n", d.Summary(), d.Detail())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,316 @@
+package generate
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// skippedBlocks are never written, they only configure Terraform itself.
+var skippedBlocks = map[string]bool{
+	"timeouts": true,
+}
+
+// writeBody writes the configurable values of a resource read from the API
+// into body. Computed-only and write-only attributes and null values are left
+// out, overrides replace the value of top-level attributes, e.g. with
+// references to other resources.
+func writeBody(body *hclwrite.Body, block *tfprotov6.SchemaBlock, value tftypes.Value, overrides map[string]hclwrite.Tokens) error {
+	values := map[string]tftypes.Value{}
+	if err := value.As(&values); err != nil {
+		return err
+	}
+
+	attributes := append([]*tfprotov6.SchemaAttribute(nil), block.Attributes...)
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Name < attributes[j].Name })
+	for _, a := range attributes {
+		if !a.Required && !a.Optional || a.WriteOnly {
+			continue
+		}
+		if tokens, ok := overrides[a.Name]; ok {
+			body.SetAttributeRaw(a.Name, tokens)
+			continue
+		}
+		v := values[a.Name]
+		if v.IsNull() || !v.IsKnown() {
+			continue
+		}
+		var ctyValue cty.Value
+		var err error
+		if a.NestedType != nil {
+			ctyValue, err = nestedCtyValue(a.NestedType, v)
+		} else {
+			ctyValue, err = toCtyValue(v)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", a.Name, err)
+		}
+		body.SetAttributeValue(a.Name, ctyValue)
+	}
+
+	blocks := append([]*tfprotov6.SchemaNestedBlock(nil), block.BlockTypes...)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].TypeName < blocks[j].TypeName })
+	for _, b := range blocks {
+		if skippedBlocks[b.TypeName] {
+			continue
+		}
+		v := values[b.TypeName]
+		if v.IsNull() || !v.IsKnown() {
+			continue
+		}
+
+		var elements []tftypes.Value
+		switch b.Nesting {
+		case tfprotov6.SchemaNestedBlockNestingModeSingle, tfprotov6.SchemaNestedBlockNestingModeGroup:
+			elements = []tftypes.Value{v}
+		case tfprotov6.SchemaNestedBlockNestingModeList, tfprotov6.SchemaNestedBlockNestingModeSet:
+			if err := v.As(&elements); err != nil {
+				return fmt.Errorf("%s: %w", b.TypeName, err)
+			}
+		default:
+			return fmt.Errorf("%s: unsupported block nesting %v", b.TypeName, b.Nesting)
+		}
+
+		for _, element := range elements {
+			complete, err := hasRequiredValues(b.Block, element)
+			if err != nil {
+				return fmt.Errorf("%s: %w", b.TypeName, err)
+			}
+			if !complete {
+				// Secrets are not returned by the API, the block has to be
+				// added by hand when it should be managed.
+				body.AppendUnstructuredTokens(hclwrite.Tokens{{
+					Type:  hclsyntax.TokenComment,
+					Bytes: []byte(fmt.Sprintf("# %s omitted, the API does not return all of its required values\n", b.TypeName)),
+				}})
+				continue
+			}
+			nested := body.AppendNewBlock(b.TypeName, nil)
+			if err := writeBody(nested.Body(), b.Block, element, nil); err != nil {
+				return fmt.Errorf("%s: %w", b.TypeName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasRequiredValues reports whether all required attributes of a block are set.
+func hasRequiredValues(block *tfprotov6.SchemaBlock, value tftypes.Value) (bool, error) {
+	values := map[string]tftypes.Value{}
+	if err := value.As(&values); err != nil {
+		return false, err
+	}
+	for _, a := range block.Attributes {
+		if a.Required && values[a.Name].IsNull() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// nestedCtyValue converts the value of a nested attribute, leaving out the
+// attributes that can't be configured. Collections become tuples and objects,
+// as their elements may differ in the attributes set.
+func nestedCtyValue(object *tfprotov6.SchemaObject, v tftypes.Value) (cty.Value, error) {
+	if object.Nesting == tfprotov6.SchemaObjectNestingModeSingle {
+		return nestedObjectCtyValue(object.Attributes, v)
+	}
+
+	if object.Nesting == tfprotov6.SchemaObjectNestingModeMap {
+		var elements map[string]tftypes.Value
+		if err := v.As(&elements); err != nil {
+			return cty.NilVal, err
+		}
+		vals := make(map[string]cty.Value, len(elements))
+		for k, e := range elements {
+			val, err := nestedObjectCtyValue(object.Attributes, e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[k] = val
+		}
+		return cty.ObjectVal(vals), nil
+	}
+
+	var elements []tftypes.Value
+	if err := v.As(&elements); err != nil {
+		return cty.NilVal, err
+	}
+	vals := make([]cty.Value, 0, len(elements))
+	for _, e := range elements {
+		val, err := nestedObjectCtyValue(object.Attributes, e)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		vals = append(vals, val)
+	}
+	return cty.TupleVal(vals), nil
+}
+
+func nestedObjectCtyValue(attributes []*tfprotov6.SchemaAttribute, v tftypes.Value) (cty.Value, error) {
+	values := map[string]tftypes.Value{}
+	if err := v.As(&values); err != nil {
+		return cty.NilVal, err
+	}
+
+	vals := map[string]cty.Value{}
+	for _, a := range attributes {
+		e := values[a.Name]
+		if !a.Required && !a.Optional || a.WriteOnly || e.IsNull() || !e.IsKnown() {
+			continue
+		}
+		var val cty.Value
+		var err error
+		if a.NestedType != nil {
+			val, err = nestedCtyValue(a.NestedType, e)
+		} else {
+			val, err = toCtyValue(e)
+		}
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("%s: %w", a.Name, err)
+		}
+		vals[a.Name] = val
+	}
+	return cty.ObjectVal(vals), nil
+}
+
+// toCtyValue converts a value of the plugin protocol into one hclwrite can write.
+func toCtyValue(v tftypes.Value) (cty.Value, error) {
+	typ := v.Type()
+	if v.IsNull() {
+		ctyType, err := toCtyType(typ)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.NullVal(ctyType), nil
+	}
+
+	switch {
+	case typ.Is(tftypes.String):
+		var s string
+		if err := v.As(&s); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.StringVal(s), nil
+	case typ.Is(tftypes.Bool):
+		var b bool
+		if err := v.As(&b); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.BoolVal(b), nil
+	case typ.Is(tftypes.Number):
+		n := new(big.Float)
+		if err := v.As(&n); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.NumberVal(n), nil
+	case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}):
+		var elements []tftypes.Value
+		if err := v.As(&elements); err != nil {
+			return cty.NilVal, err
+		}
+		if len(elements) == 0 {
+			ctyType, err := toCtyType(typ)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			if typ.Is(tftypes.Set{}) {
+				return cty.SetValEmpty(ctyType.ElementType()), nil
+			}
+			return cty.ListValEmpty(ctyType.ElementType()), nil
+		}
+		vals := make([]cty.Value, 0, len(elements))
+		for _, e := range elements {
+			val, err := toCtyValue(e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals = append(vals, val)
+		}
+		if typ.Is(tftypes.Set{}) {
+			return cty.SetVal(vals), nil
+		}
+		return cty.ListVal(vals), nil
+	case typ.Is(tftypes.Map{}), typ.Is(tftypes.Object{}):
+		var elements map[string]tftypes.Value
+		if err := v.As(&elements); err != nil {
+			return cty.NilVal, err
+		}
+		vals := make(map[string]cty.Value, len(elements))
+		for k, e := range elements {
+			val, err := toCtyValue(e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[k] = val
+		}
+		if typ.Is(tftypes.Object{}) {
+			return cty.ObjectVal(vals), nil
+		}
+		if len(vals) == 0 {
+			ctyType, err := toCtyType(typ)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.MapValEmpty(ctyType.ElementType()), nil
+		}
+		return cty.MapVal(vals), nil
+	}
+
+	return cty.NilVal, fmt.Errorf("unsupported type %s", typ)
+}
+
+func toCtyType(typ tftypes.Type) (cty.Type, error) {
+	switch t := typ.(type) {
+	case tftypes.List:
+		e, err := toCtyType(t.ElementType)
+		return cty.List(e), err
+	case tftypes.Set:
+		e, err := toCtyType(t.ElementType)
+		return cty.Set(e), err
+	case tftypes.Map:
+		e, err := toCtyType(t.ElementType)
+		return cty.Map(e), err
+	case tftypes.Object:
+		attributes := make(map[string]cty.Type, len(t.AttributeTypes))
+		for k, a := range t.AttributeTypes {
+			ct, err := toCtyType(a)
+			if err != nil {
+				return cty.NilType, err
+			}
+			attributes[k] = ct
+		}
+		return cty.Object(attributes), nil
+	}
+
+	switch {
+	case typ.Is(tftypes.String):
+		return cty.String, nil
+	case typ.Is(tftypes.Bool):
+		return cty.Bool, nil
+	case typ.Is(tftypes.Number):
+		return cty.Number, nil
+	}
+	return cty.NilType, fmt.Errorf("unsupported type %s", typ)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// resourceName turns the name of a MetaKube object into a Terraform resource
+// name, which must start with a letter or underscore.
+func resourceName(name string) string {
+	n := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if n == "" || n[0] >= '0' && n[0] <= '9' || n[0] == '-' {
+		n = "r_" + n
+	}
+	return n
+}
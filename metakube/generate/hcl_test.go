@@ -0,0 +1,118 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestWriteBody(t *testing.T) {
+	credentialsType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"id":     tftypes.String,
+		"secret": tftypes.String,
+	}}
+	cniType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"type":    tftypes.String,
+		"version": tftypes.String,
+	}}
+	schema := &tfprotov6.SchemaBlock{
+		Attributes: []*tfprotov6.SchemaAttribute{
+			{Name: "id", Type: tftypes.String, Computed: true},
+			{Name: "name", Type: tftypes.String, Required: true},
+			{Name: "labels", Type: tftypes.Map{ElementType: tftypes.String}, Optional: true},
+			{Name: "description", Type: tftypes.String, Optional: true},
+			{Name: "secret_wo", Type: tftypes.String, Optional: true, WriteOnly: true},
+			{Name: "cluster_id", Type: tftypes.String, Required: true},
+			{Name: "cni_plugin", Type: cniType, Optional: true, NestedType: &tfprotov6.SchemaObject{
+				Nesting: tfprotov6.SchemaObjectNestingModeSingle,
+				Attributes: []*tfprotov6.SchemaAttribute{
+					{Name: "type", Type: tftypes.String, Optional: true},
+					{Name: "version", Type: tftypes.String, Computed: true},
+				},
+			}},
+		},
+		BlockTypes: []*tfprotov6.SchemaNestedBlock{
+			{
+				TypeName: "credentials",
+				Nesting:  tfprotov6.SchemaNestedBlockNestingModeList,
+				Block: &tfprotov6.SchemaBlock{
+					Attributes: []*tfprotov6.SchemaAttribute{
+						{Name: "id", Type: tftypes.String, Required: true},
+						{Name: "secret", Type: tftypes.String, Required: true, Sensitive: true},
+					},
+				},
+			},
+		},
+	}
+	typ := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"id":          tftypes.String,
+		"name":        tftypes.String,
+		"labels":      tftypes.Map{ElementType: tftypes.String},
+		"description": tftypes.String,
+		"secret_wo":   tftypes.String,
+		"cluster_id":  tftypes.String,
+		"cni_plugin":  cniType,
+		"credentials": tftypes.List{ElementType: credentialsType},
+	}}
+	value := tftypes.NewValue(typ, map[string]tftypes.Value{
+		"id":          tftypes.NewValue(tftypes.String, "abc"),
+		"name":        tftypes.NewValue(tftypes.String, "prod ${env}"),
+		"labels":      tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{"env": tftypes.NewValue(tftypes.String, "prod")}),
+		"description": tftypes.NewValue(tftypes.String, nil),
+		"secret_wo":   tftypes.NewValue(tftypes.String, nil),
+		"cluster_id":  tftypes.NewValue(tftypes.String, "c1"),
+		"cni_plugin": tftypes.NewValue(cniType, map[string]tftypes.Value{
+			"type":    tftypes.NewValue(tftypes.String, "cilium"),
+			"version": tftypes.NewValue(tftypes.String, "1.15"),
+		}),
+		"credentials": tftypes.NewValue(tftypes.List{ElementType: credentialsType}, []tftypes.Value{
+			tftypes.NewValue(credentialsType, map[string]tftypes.Value{
+				"id":     tftypes.NewValue(tftypes.String, "app"),
+				"secret": tftypes.NewValue(tftypes.String, nil),
+			}),
+		}),
+	})
+
+	f := hclwrite.NewFile()
+	block := f.Body().AppendNewBlock("resource", []string{"metakube_example", "prod"})
+	overrides := map[string]hclwrite.Tokens{
+		"cluster_id": hclwrite.TokensForIdentifier("metakube_cluster.prod.id"),
+	}
+	if err := writeBody(block.Body(), schema, value, overrides); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `resource "metakube_example" "prod" {
+  cluster_id = metakube_cluster.prod.id
+  cni_plugin = {
+    type = "cilium"
+  }
+  labels = {
+    env = "prod"
+  }
+  name = "prod $${env}"
+  # credentials omitted, the API does not return all of its required values
+}
+`
+	if diff := cmp.Diff(expected, string(f.Bytes())); diff != "" {
+		t.Errorf("unexpected configuration (-want +got):\n%s", diff)
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	cases := map[string]string{
+		"prod":                  "prod",
+		"My Cluster":            "my_cluster",
+		"prod_kube-system_view": "prod_kube-system_view",
+		"1st":                   "r_1st",
+		"äöü":                   "r_",
+	}
+	for in, expected := range cases {
+		if got := resourceName(in); got != expected {
+			t.Errorf("resourceName(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
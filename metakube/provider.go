@@ -1,29 +1,34 @@
 package metakube
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
-	"net/url"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/go-openapi/runtime"
-	"github.com/go-openapi/strfmt"
-	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/list"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	frameworkSchema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	pluginSchema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/mitchellh/go-homedir"
-	k8client "github.com/syseleven/go-metakube/client"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common"
 	"github.com/syseleven/terraform-provider-metakube/metakube/datasources/datasource_k8s_version"
 	"github.com/syseleven/terraform-provider-metakube/metakube/datasources/datasource_project"
 	"github.com/syseleven/terraform-provider-metakube/metakube/datasources/datasource_sshkey"
+	"github.com/syseleven/terraform-provider-metakube/metakube/ephemeralresources/ephemeralresource_cluster_kubeconfig"
+	"github.com/syseleven/terraform-provider-metakube/metakube/functions/function_kubeconfig_decode"
+	"github.com/syseleven/terraform-provider-metakube/metakube/functions/function_kubeconfig_set_context"
 	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_cluster"
 	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_cluster_role_binding"
 	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_maintenance_cronjob"
@@ -32,201 +37,19 @@ import (
 	"github.com/syseleven/terraform-provider-metakube/metakube/resources/resource_sshkey"
 
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
-// Provider returns a schema.Provider for MetaKube.
-func Provider() *pluginSchema.Provider {
-	p := &pluginSchema.Provider{
-		Schema: map[string]*pluginSchema.Schema{
-			"host": {
-				Type:        pluginSchema.TypeString,
-				Description: "The hostname of MetaKube API (in form of URI)",
-				Optional:    true,
-				DefaultFunc: pluginSchema.EnvDefaultFunc("METAKUBE_HOST", "https://metakube.syseleven.de"),
-			},
-			"token": {
-				Type:        pluginSchema.TypeString,
-				Description: "The MetaKube authentication token",
-				Optional:    true,
-				Sensitive:   true,
-				DefaultFunc: pluginSchema.EnvDefaultFunc("METAKUBE_TOKEN", ""),
-			},
-			"token_path": {
-				Type:        pluginSchema.TypeString,
-				Description: "Path to the MetaKube authentication token, defaults to ~/.metakube/auth",
-				Optional:    true,
-				DefaultFunc: pluginSchema.MultiEnvDefaultFunc(
-					[]string{
-						"METAKUBE_TOKEN_PATH",
-					}, "~/.metakube/auth"),
-			},
-			"development": {
-				Type:        pluginSchema.TypeBool,
-				Description: "Run development mode.",
-				Optional:    true,
-				Default:     false,
-			},
-			"debug": {
-				Type:        pluginSchema.TypeBool,
-				Description: "Run debug mode.",
-				Optional:    true,
-				Default:     false,
-			},
-			"log_path": {
-				Type:        pluginSchema.TypeString,
-				Description: "Path to store logs",
-				Optional:    true,
-				Default:     "",
-			},
-		},
-
-		ResourcesMap: map[string]*pluginSchema.Resource{
-			"metakube_sshkey":               resource_sshkey.MetakubeResourceSSHKey(),
-		},
-	}
-
-	// copying stderr because of https://github.com/hashicorp/go-plugin/issues/93
-	// as an example the standard log pkg points to the "old" stderr
-	stderr := os.Stderr
-
-	p.ConfigureContextFunc = func(_ context.Context, d *pluginSchema.ResourceData) (interface{}, diag.Diagnostics) {
-		terraformVersion := p.TerraformVersion
-		if terraformVersion == "" {
-			// Terraform 0.12 introduced this field to the protocol
-			// We can therefore assume that if it's missing it's 0.10 or 0.11
-			terraformVersion = "0.11+compatible"
-		}
-		return configure(d, terraformVersion, stderr)
-	}
-
-	return p
-}
-
-func configure(d *pluginSchema.ResourceData, terraformVersion string, fd *os.File) (interface{}, diag.Diagnostics) {
-	var (
-		k                common.MetaKubeProviderMeta
-		diagnostics, tmp diag.Diagnostics
-	)
-
-	k.Log, tmp = newLogger(d, fd)
-	diagnostics = append(diagnostics, tmp...)
-	k.Client, tmp = newClient(d.Get("host").(string))
-	diagnostics = append(diagnostics, tmp...)
-
-	k.Auth, tmp = newAuth(d.Get("token").(string), d.Get("token_path").(string), terraformVersion)
-	diagnostics = append(diagnostics, tmp...)
-
-	return &k, diagnostics
-}
-
-func newLogger(d *pluginSchema.ResourceData, fd *os.File) (*zap.SugaredLogger, diag.Diagnostics) {
-	var (
-		ec    zapcore.EncoderConfig
-		cores []zapcore.Core
-		level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	)
-
-	logDev := d.Get("development").(bool)
-	logDebug := d.Get("debug").(bool)
-	logPath := d.Get("log_path").(string)
-
-	if logDev || logDebug {
-		level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
-	}
-
-	if logDev {
-		ec = zap.NewDevelopmentEncoderConfig()
-		ec.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	} else {
-		ec = zap.NewProductionEncoderConfig()
-		ec.EncodeLevel = func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
-			enc.AppendString("[" + level.CapitalString() + "]")
-		}
-	}
-	ec.EncodeTime = zapcore.ISO8601TimeEncoder
-	ec.EncodeDuration = zapcore.StringDurationEncoder
-
-	if logPath != "" {
-		jsonEC := ec
-		jsonEC.EncodeLevel = zapcore.LowercaseLevelEncoder
-		sink, _, err := zap.Open(logPath)
-		if err != nil {
-			return nil, diag.Diagnostics{{
-				Severity:      diag.Error,
-				Summary:       fmt.Sprintf("Can't access location: %v", err),
-				AttributePath: cty.Path{cty.GetAttrStep{Name: "log_path"}},
-			}}
-		}
-		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(jsonEC), sink, level))
-	}
-
-	cores = append(cores, zapcore.NewCore(zapcore.NewConsoleEncoder(ec), zapcore.AddSync(fd), level))
-	core := zapcore.NewTee(cores...)
-	return zap.New(core).Sugar(), nil
-}
-
-func newClient(host string) (*k8client.MetaKubeAPI, diag.Diagnostics) {
-	u, err := url.Parse(host)
-	if err != nil {
-		return nil, diag.Diagnostics{{
-			Severity:      diag.Error,
-			Summary:       fmt.Sprintf("Can't parse host: %v", err),
-			AttributePath: cty.Path{cty.GetAttrStep{Name: "host"}},
-		}}
-	}
-
-	return k8client.NewHTTPClientWithConfig(nil, &k8client.TransportConfig{
-		Host:     u.Host,
-		BasePath: u.Path,
-		Schemes:  []string{u.Scheme},
-	}), nil
-}
-
-func newAuth(token, tokenPath, terraformVersion string) (runtime.ClientAuthInfoWriter, diag.Diagnostics) {
-	if token == "" && tokenPath != "" {
-		p, err := homedir.Expand(tokenPath)
-		if err != nil {
-			return nil, diag.Diagnostics{{
-				Severity:      diag.Error,
-				Summary:       fmt.Sprintf("Can't parse path: %v", err),
-				AttributePath: cty.Path{cty.GetAttrStep{Name: "token_path"}},
-			}}
-		}
-		rawToken, err := ioutil.ReadFile(p)
-		if err != nil {
-			return nil, diag.Diagnostics{{
-				Severity:      diag.Error,
-				Summary:       fmt.Sprintf("Can't read token file: %v", err),
-				AttributePath: cty.Path{cty.GetAttrStep{Name: "token_path"}},
-			}}
-		}
-		token = string(bytes.Trim(rawToken, "\n"))
-	} else if token == "" {
-		return nil, diag.Diagnostics{{
-			Severity:      diag.Error,
-			Summary:       "Missing authorization token",
-			AttributePath: cty.Path{cty.GetAttrStep{Name: "token_path"}, cty.GetAttrStep{Name: "token"}},
-		}}
-	}
-
-	auth := runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
-		err := r.SetHeaderParam("Authorization", "Bearer "+token)
-		if err != nil {
-			return err
-		}
-		return r.SetHeaderParam("User-Agent", fmt.Sprintf("Terraform/%s", terraformVersion))
-	})
-	return auth, nil
-}
-
-// Terraform Plugin Framework Provider
-
-var _ provider.Provider = &metakubeProvider{}
+var (
+	_ provider.Provider                       = &metakubeProvider{}
+	_ provider.ProviderWithEphemeralResources = &metakubeProvider{}
+	_ provider.ProviderWithListResources      = &metakubeProvider{}
+	_ provider.ProviderWithFunctions          = &metakubeProvider{}
+)
 
 type metakubeProvider struct{}
 
-func NewFrameworkProvider() provider.Provider {
+// NewProvider returns the MetaKube provider.
+func NewProvider() provider.Provider {
 	return &metakubeProvider{}
 }
 
@@ -246,18 +69,144 @@ func (p *metakubeProvider) Schema(ctx context.Context, req provider.SchemaReques
 				Description: "Path to the MetaKube authentication token, defaults to ~/.metakube/auth",
 				Optional:    true,
 			},
-			"development": frameworkSchema.BoolAttribute{
-				Description: "Run development mode.",
+			"token_command": frameworkSchema.ListAttribute{
+				Description: "Command and arguments of an executable that prints the MetaKube authentication token and its expiry as JSON",
 				Optional:    true,
+				ElementType: types.StringType,
 			},
-			"debug": frameworkSchema.BoolAttribute{
-				Description: "Run debug mode.",
+			"profile": frameworkSchema.StringAttribute{
+				Description: "Name of the profile in ~/.metakube/config to take host, credentials and default project from",
+				Optional:    true,
+			},
+			"log_level": frameworkSchema.StringAttribute{
+				Description: "Minimum level of provider logs: trace, debug, info, warn or error. Defaults to the level set by TF_LOG_PROVIDER",
 				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("trace", "debug", "info", "warn", "error"),
+				},
+			},
+			"development": frameworkSchema.BoolAttribute{
+				Description:        "Run development mode.",
+				Optional:           true,
+				DeprecationMessage: "Use log_level instead.",
+			},
+			"debug": frameworkSchema.BoolAttribute{
+				Description:        "Run debug mode.",
+				Optional:           true,
+				DeprecationMessage: "Use log_level = \"debug\" instead.",
 			},
 			"log_path": frameworkSchema.StringAttribute{
 				Description: "Path to store logs",
 				Optional:    true,
 			},
+			"ca_file": frameworkSchema.StringAttribute{
+				Description: "Path to a PEM encoded CA bundle to trust in addition to the system roots",
+				Optional:    true,
+			},
+			"ca_pem": frameworkSchema.StringAttribute{
+				Description: "PEM encoded CA bundle to trust in addition to the system roots",
+				Optional:    true,
+			},
+			"client_cert": frameworkSchema.StringAttribute{
+				Description: "PEM encoded client certificate, or the path to it, for TLS client authentication",
+				Optional:    true,
+			},
+			"client_key": frameworkSchema.StringAttribute{
+				Description: "PEM encoded client certificate key, or the path to it, for TLS client authentication",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"proxy_url": frameworkSchema.StringAttribute{
+				Description: "URL of the HTTP proxy to use, defaults to the HTTPS_PROXY environment variable",
+				Optional:    true,
+			},
+			"insecure_skip_verify": frameworkSchema.BoolAttribute{
+				Description: "Skip verification of the API server certificate. Do not use in production",
+				Optional:    true,
+			},
+			"skip_remote_validation": frameworkSchema.BoolAttribute{
+				Description: "Skip the checks against the MetaKube API during plan, they are reported as warnings and run during apply instead. Allows planning without API access when combined with skip_preflight and -refresh=false",
+				Optional:    true,
+			},
+			"read_only": frameworkSchema.BoolAttribute{
+				Description: "Refuse to create, update or delete resources, only reading them. Requests to the API other than GET are rejected as well",
+				Optional:    true,
+			},
+			"skip_kubeconfigs": frameworkSchema.BoolAttribute{
+				Description: "Do not store kube_config, oidc_kube_config and kube_login_kube_config of clusters in the state. Use the metakube_cluster_kubeconfig ephemeral resource to obtain them instead",
+				Optional:    true,
+			},
+			"retry_max_attempts": frameworkSchema.Int64Attribute{
+				Description: "How often an idempotent request is sent at most when the API answers 429, 502, 503 or 504, defaults to 5. Set to 1 to disable retries",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"max_requests_per_second": frameworkSchema.Float64Attribute{
+				Description: "Maximum rate of requests to the MetaKube API, shared by all resources and data sources. Unlimited by default",
+				Optional:    true,
+				Validators: []validator.Float64{
+					float64validator.AtLeast(0),
+				},
+			},
+			"max_concurrent_requests": frameworkSchema.Int64Attribute{
+				Description: "Maximum number of requests to the MetaKube API in flight at the same time. Unlimited by default",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"trace_http": frameworkSchema.BoolAttribute{
+				Description: "Log every API request and response, with credentials redacted, into the log file at log_path",
+				Optional:    true,
+			},
+			"oidc_issuer_url": frameworkSchema.StringAttribute{
+				Description: "URL of the OIDC issuer to obtain access tokens from. Enables OIDC authentication instead of a static token",
+				Optional:    true,
+			},
+			"oidc_client_id": frameworkSchema.StringAttribute{
+				Description: "OIDC client ID",
+				Optional:    true,
+			},
+			"oidc_client_secret": frameworkSchema.StringAttribute{
+				Description: "OIDC client secret. Used for the client credentials grant when no refresh token is given",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"oidc_refresh_token": frameworkSchema.StringAttribute{
+				Description: "OIDC refresh token used to obtain access tokens",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"token_expiry_warning": frameworkSchema.StringAttribute{
+				Description: "Warn when the token expires within this duration, e.g. \"1h\". Tokens refreshed by token_command or OIDC are not checked. Defaults to 30m, 0 disables the warning",
+				Optional:    true,
+			},
+			"skip_preflight": frameworkSchema.BoolAttribute{
+				Description: "Skip the check of the connection, the credentials and the token expiry when the provider is configured",
+				Optional:    true,
+			},
+			"default_labels": frameworkSchema.MapAttribute{
+				Description: "Labels added to all clusters and node deployments, and as tags to their instances. Labels set on a resource take precedence",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"ignore_label_prefixes": frameworkSchema.ListAttribute{
+				Description: "Prefixes of label keys managed by MetaKube or Kubernetes, which are not compared with the configuration but exposed in system_labels. A prefix ending with \"/\" also matches subdomains. Defaults to system/, system-, kubernetes.io/, k8s.io/ and metakube.syseleven.de/",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"ignore_label_keys": frameworkSchema.ListAttribute{
+				Description: "Label keys managed outside of the configuration, which are not compared with it but exposed in system_labels",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"protect_labels": frameworkSchema.MapAttribute{
+				Description: "Label selector protecting clusters and node deployments from deletion and replacement, e.g. { env = \"prod\" }. A resource is protected when its labels, including default_labels, contain all of these labels, as if deletion_protection was set",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
 		},
 	}
 }
@@ -271,6 +220,16 @@ func (p *metakubeProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
+	// Settings referencing other resources are unknown until those are
+	// applied. When Terraform supports it, defer all resources and data
+	// sources of this provider to a later plan instead of failing.
+	if req.ClientCapabilities.DeferralAllowed && !req.Config.Raw.IsFullyKnown() {
+		resp.Deferred = &provider.Deferred{
+			Reason: provider.DeferredReasonProviderConfigUnknown,
+		}
+		return
+	}
+
 	// Check for unknown values
 	if config.Host.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
@@ -297,6 +256,42 @@ func (p *metakubeProvider) Configure(ctx context.Context, req provider.Configure
 		}
 	}
 
+	for name, value := range map[string]attr.Value{
+		"profile":                 config.Profile,
+		"token_command":           config.TokenCommand,
+		"ca_file":                 config.CAFile,
+		"ca_pem":                  config.CAPEM,
+		"client_cert":             config.ClientCert,
+		"client_key":              config.ClientKey,
+		"proxy_url":               config.ProxyURL,
+		"insecure_skip_verify":    config.InsecureSkipVerify,
+		"trace_http":              config.TraceHTTP,
+		"retry_max_attempts":      config.RetryMaxAttempts,
+		"max_requests_per_second": config.MaxRequestsPerSecond,
+		"max_concurrent_requests": config.MaxConcurrentRequests,
+		"oidc_issuer_url":         config.OIDCIssuerURL,
+		"oidc_client_id":          config.OIDCClientID,
+		"oidc_client_secret":      config.OIDCClientSecret,
+		"oidc_refresh_token":      config.OIDCRefreshToken,
+		"token_expiry_warning":    config.TokenExpiryWarning,
+		"skip_preflight":          config.SkipPreflight,
+		"skip_remote_validation":  config.SkipRemoteValidation,
+		"read_only":               config.ReadOnly,
+		"skip_kubeconfigs":        config.SkipKubeconfigs,
+		"default_labels":          config.DefaultLabels,
+		"ignore_label_prefixes":   config.IgnoreLabelPrefixes,
+		"ignore_label_keys":       config.IgnoreLabelKeys,
+		"protect_labels":          config.ProtectLabels,
+	} {
+		if value.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Unknown MetaKube provider setting",
+				fmt.Sprintf("The value of %s is unknown", name),
+			)
+		}
+	}
+
 	if config.Development.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("development"),
@@ -309,52 +304,203 @@ func (p *metakubeProvider) Configure(ctx context.Context, req provider.Configure
 		return
 	}
 
-	// Set default values
-	host := config.Host.ValueString()
-	if host == "" {
-		host = os.Getenv("METAKUBE_HOST")
-		if host == "" {
-			host = "https://metakube.syseleven.de"
+	explicit := common.ConnectionSettings{
+		Profile:   config.Profile.ValueString(),
+		Host:      config.Host.ValueString(),
+		Token:     config.Token.ValueString(),
+		TokenPath: config.TokenPath.ValueString(),
+	}
+	if !config.TokenCommand.IsNull() {
+		resp.Diagnostics.Append(config.TokenCommand.ElementsAs(ctx, &explicit.TokenCommand, false)...)
+		if resp.Diagnostics.HasError() {
+			return
 		}
 	}
 
-	token := config.Token.ValueString()
-	if token == "" {
-		token = os.Getenv("METAKUBE_TOKEN")
+	settings, err := common.ResolveConnectionSettings(explicit)
+	resp.Diagnostics.Append(common.ProfileToFrameworkDiagnostics(err)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	tokenPath := config.TokenPath.ValueString()
-	if tokenPath == "" {
-		tokenPath = os.Getenv("METAKUBE_TOKEN_PATH")
-		if tokenPath == "" {
-			tokenPath = "~/.metakube/auth"
+	oidc := common.OIDCConfig{
+		IssuerURL:    stringValueOrEnv(config.OIDCIssuerURL, "METAKUBE_OIDC_ISSUER_URL"),
+		ClientID:     stringValueOrEnv(config.OIDCClientID, "METAKUBE_OIDC_CLIENT_ID"),
+		ClientSecret: stringValueOrEnv(config.OIDCClientSecret, "METAKUBE_OIDC_CLIENT_SECRET"),
+		RefreshToken: stringValueOrEnv(config.OIDCRefreshToken, "METAKUBE_OIDC_REFRESH_TOKEN"),
+	}
+
+	k := common.MetaKubeProviderMeta{
+		DefaultProjectID: settings.DefaultProjectID,
+	}
+
+	if !config.DefaultLabels.IsNull() {
+		resp.Diagnostics.Append(config.DefaultLabels.ElementsAs(ctx, &k.DefaultLabels, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !config.ProtectLabels.IsNull() {
+		resp.Diagnostics.Append(config.ProtectLabels.ElementsAs(ctx, &k.ProtectLabels, false)...)
+		if resp.Diagnostics.HasError() {
+			return
 		}
 	}
 
-	var k common.MetaKubeProviderMeta
+	var ignorePrefixes, ignoreKeys []string
+	if !config.IgnoreLabelPrefixes.IsNull() {
+		// An empty list disables the default prefixes.
+		ignorePrefixes = []string{}
+		resp.Diagnostics.Append(config.IgnoreLabelPrefixes.ElementsAs(ctx, &ignorePrefixes, false)...)
+	}
+	if !config.IgnoreLabelKeys.IsNull() {
+		resp.Diagnostics.Append(config.IgnoreLabelKeys.ElementsAs(ctx, &ignoreKeys, false)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	k.LabelFilter = common.NewLabelFilter(ignorePrefixes, ignoreKeys)
 
-	var err error
-	k.Log, err = common.NewLogger(config, os.Stderr)
+	var trace *zap.Logger
+	config.LogLevel = types.StringValue(stringValueOrEnv(config.LogLevel, "METAKUBE_LOG_LEVEL"))
+	config.LogPath = types.StringValue(stringValueOrEnv(config.LogPath, "METAKUBE_LOG_PATH"))
+	k.Log, trace, err = common.NewLogger(config)
 	resp.Diagnostics.Append(common.LoggerToFrameworkDiagnostics(err)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	k.Client, diags = common.NewClient(host)
-	resp.Diagnostics.Append(diags...)
+	baseTransport, err := common.NewTransport(common.TLSConfig{
+		CAFile:             stringValueOrEnv(config.CAFile, "METAKUBE_CA_FILE"),
+		CAPEM:              stringValueOrEnv(config.CAPEM, "METAKUBE_CA_PEM"),
+		ClientCert:         stringValueOrEnv(config.ClientCert, "METAKUBE_CLIENT_CERT"),
+		ClientKey:          stringValueOrEnv(config.ClientKey, "METAKUBE_CLIENT_KEY"),
+		ProxyURL:           stringValueOrEnv(config.ProxyURL, "METAKUBE_PROXY_URL"),
+		InsecureSkipVerify: boolValueOrEnv(config.InsecureSkipVerify, "METAKUBE_INSECURE_SKIP_VERIFY"),
+	})
+	resp.Diagnostics.Append(common.TransportToFrameworkDiagnostics(err)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	var authErr error
-	k.Auth, authErr = common.NewAuth(token, tokenPath, "1.0+")
-	resp.Diagnostics.Append(common.ToFrameworkDiagnostics(authErr)...)
+	retryMaxAttempts := int64(common.DefaultRetryMaxAttempts)
+	if !config.RetryMaxAttempts.IsNull() {
+		retryMaxAttempts = config.RetryMaxAttempts.ValueInt64()
+	} else if v, err := strconv.ParseInt(os.Getenv("METAKUBE_RETRY_MAX_ATTEMPTS"), 10, 64); err == nil {
+		retryMaxAttempts = v
+	}
+
+	maxRequestsPerSecond := config.MaxRequestsPerSecond.ValueFloat64()
+	if config.MaxRequestsPerSecond.IsNull() {
+		maxRequestsPerSecond, _ = strconv.ParseFloat(os.Getenv("METAKUBE_MAX_REQUESTS_PER_SECOND"), 64)
+	}
+	maxConcurrentRequests := config.MaxConcurrentRequests.ValueInt64()
+	if config.MaxConcurrentRequests.IsNull() {
+		maxConcurrentRequests, _ = strconv.ParseInt(os.Getenv("METAKUBE_MAX_CONCURRENT_REQUESTS"), 10, 64)
+	}
+	k.Limiter = common.NewRequestLimiter(maxRequestsPerSecond, int(maxConcurrentRequests))
+
+	k.ReadOnly = boolValueOrEnv(config.ReadOnly, "METAKUBE_READ_ONLY")
+	k.SkipKubeconfigs = boolValueOrEnv(config.SkipKubeconfigs, "METAKUBE_SKIP_KUBECONFIGS")
+
+	// OIDC token requests use baseTransport, so refreshing tokens keeps working in read_only mode.
+	var apiTransport http.RoundTripper = baseTransport
+	if k.ReadOnly {
+		apiTransport = common.NewReadOnlyTransport(apiTransport)
+	}
+	if trace != nil {
+		apiTransport = common.NewTraceTransport(apiTransport, trace)
+	}
+
+	var (
+		limitedTransport = common.NewLimitedTransport(apiTransport, k.Limiter)
+		transport        = common.NewRetryTransport(limitedTransport, int(retryMaxAttempts), k.Log)
+		// The preflight is not retried, waiting for Retry-After would only run into its timeout.
+		preflightTransport = limitedTransport
+		oidcSource         *common.OIDCTokenSource
+	)
+	if oidc.Enabled() {
+		oidcSource, err = common.NewOIDCTokenSource(oidc, &http.Client{Transport: baseTransport})
+		resp.Diagnostics.Append(common.ToFrameworkDiagnostics(err)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		transport = common.NewOIDCTransport(transport, oidcSource)
+		preflightTransport = common.NewOIDCTransport(preflightTransport, oidcSource)
+	}
+
+	tokenExpiryWarning := common.DefaultTokenExpiryWarning
+	if v := stringValueOrEnv(config.TokenExpiryWarning, "METAKUBE_TOKEN_EXPIRY_WARNING"); v != "" {
+		if tokenExpiryWarning, err = time.ParseDuration(v); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("token_expiry_warning"),
+				"Invalid token expiry warning",
+				fmt.Sprintf("Can't parse %q as a duration: %v", v, err),
+			)
+			return
+		}
+	}
+
+	k.Client, diags = common.NewClient(settings.Host, transport)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if oidcSource != nil {
+		// OIDC takes precedence over token and token_path.
+		k.Auth = common.NewOIDCAuth(oidcSource, "1.0+")
+	} else {
+		var authErr error
+		k.Auth, authErr = common.NewAuth(settings.Token, settings.TokenPath, settings.TokenCommand, "1.0+")
+		resp.Diagnostics.Append(common.ToFrameworkDiagnostics(authErr)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	k.SkipRemoteValidation = boolValueOrEnv(config.SkipRemoteValidation, "METAKUBE_SKIP_REMOTE_VALIDATION")
+
+	// Fail here with a precise message instead of in the first resource operation.
+	if !boolValueOrEnv(config.SkipPreflight, "METAKUBE_SKIP_PREFLIGHT") {
+		resp.Diagnostics.Append(common.Preflight(ctx, common.PreflightConfig{
+			Host:           settings.Host,
+			Transport:      preflightTransport,
+			Auth:           k.Auth,
+			TokenRefreshes: oidcSource != nil || (settings.Token == "" && len(settings.TokenCommand) > 0),
+			ExpiryWarning:  tokenExpiryWarning,
+		})...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	resp.DataSourceData = &k
 	resp.ResourceData = &k
+	resp.EphemeralResourceData = &k
+	resp.ListResourceData = &k
+}
+
+// stringValueOrEnv returns the configured value, falling back to the environment variable.
+func stringValueOrEnv(value types.String, env string) string {
+	return stringOrEnv(value.ValueString(), env)
+}
+
+func stringOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// boolValueOrEnv returns the configured value, falling back to the environment variable.
+func boolValueOrEnv(value types.Bool, env string) bool {
+	if !value.IsNull() {
+		return value.ValueBool()
+	}
+	v, _ := strconv.ParseBool(os.Getenv(env))
+	return v
 }
 
 func (p *metakubeProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
@@ -365,6 +511,27 @@ func (p *metakubeProvider) DataSources(ctx context.Context) []func() datasource.
 	}
 }
 
+func (p *metakubeProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		ephemeralresource_cluster_kubeconfig.NewClusterKubeconfig,
+	}
+}
+
+func (p *metakubeProvider) ListResources(ctx context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		resource_cluster.NewClusterListResource,
+		resource_node_deployment.NewNodeDeploymentListResource,
+		resource_sshkey.NewSSHKeyListResource,
+	}
+}
+
+func (p *metakubeProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		function_kubeconfig_decode.NewKubeconfigDecode,
+		function_kubeconfig_set_context.NewKubeconfigSetContext,
+	}
+}
+
 func (p *metakubeProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "metakube"
 }
@@ -375,7 +542,7 @@ func (p *metakubeProvider) Resources(ctx context.Context) []func() resource.Reso
 		resource_cluster_role_binding.NewClusterRoleBinding,
 		resource_role_binding.NewRoleBinding,
 		resource_node_deployment.NewNodeDeployment,
-		// metakubeResourceSSHKey,
+		resource_sshkey.NewSSHKey,
 		resource_maintenance_cronjob.NewMaintenanceCronJob,
 	}
 }
@@ -1,18 +1,19 @@
 package metakube
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/syseleven/go-metakube/client/project"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common"
-	"github.com/syseleven/terraform-provider-metakube/metakube/common/provider_testutil"
-	"go.uber.org/zap"
 )
 
 func init() {
@@ -26,13 +27,55 @@ func init() {
 	})
 }
 func TestMain(m *testing.M) {
-	provider_testutil.TestAccProvider = Provider()
-	provider_testutil.TestAccProviders = map[string]*schema.Provider{
-		"metakube": provider_testutil.TestAccProvider,
-	}
 	resource.TestMain(m)
 }
 
+func TestProviderConfigureDeferred(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider()
+
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	typ := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+	for name, attrType := range typ.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	values["host"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(typ, values),
+	}
+
+	t.Run("deferral allowed", func(t *testing.T) {
+		var resp provider.ConfigureResponse
+		p.Configure(ctx, provider.ConfigureRequest{
+			Config:             config,
+			ClientCapabilities: provider.ConfigureProviderClientCapabilities{DeferralAllowed: true},
+		}, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+		}
+		if resp.Deferred == nil || resp.Deferred.Reason != provider.DeferredReasonProviderConfigUnknown {
+			t.Fatalf("expected deferred response, got %v", resp.Deferred)
+		}
+		if resp.ResourceData != nil {
+			t.Fatalf("expected no resource data")
+		}
+	})
+
+	t.Run("deferral not allowed", func(t *testing.T) {
+		var resp provider.ConfigureResponse
+		p.Configure(ctx, provider.ConfigureRequest{Config: config}, &resp)
+		if resp.Deferred != nil {
+			t.Fatalf("unexpected deferred response")
+		}
+		if !resp.Diagnostics.HasError() {
+			t.Fatalf("expected unknown host error")
+		}
+	})
+}
+
 func testSweepClusters(region string) error {
 	meta, err := SharedConfigForRegion(region)
 	if err != nil {
@@ -93,17 +136,17 @@ func testSweepSSHKeys(region string) error {
 
 func SharedConfigForRegion(_ string) (*common.MetaKubeProviderMeta, error) {
 	host := os.Getenv("METAKUBE_HOST")
-	client, diags := common.NewClient(host)
+	client, diags := common.NewClient(host, nil)
 	if diags.HasError() {
 		return nil, fmt.Errorf("create client %v", diags.Errors())
 	}
 
 	token := os.Getenv("METAKUBE_TOKEN")
-	auth, err := common.NewAuth(token, "", "")
+	auth, err := common.NewAuth(token, "", nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("auth api %v", err)
 	}
-	log := zap.NewNop().Sugar()
+	log := common.NewNopLogger()
 	return &common.MetaKubeProviderMeta{
 		Client: client,
 		Auth:   auth,
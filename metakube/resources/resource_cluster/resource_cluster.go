@@ -7,10 +7,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/syseleven/go-metakube/client/datacenter"
 	"github.com/syseleven/go-metakube/client/project"
@@ -19,9 +21,12 @@ import (
 )
 
 var (
-	_ resource.Resource                = &clusterResource{}
-	_ resource.ResourceWithConfigure   = &clusterResource{}
-	_ resource.ResourceWithImportState = &clusterResource{}
+	_ resource.Resource                   = &clusterResource{}
+	_ resource.ResourceWithConfigure      = &clusterResource{}
+	_ resource.ResourceWithImportState    = &clusterResource{}
+	_ resource.ResourceWithModifyPlan     = &clusterResource{}
+	_ resource.ResourceWithIdentity       = &clusterResource{}
+	_ resource.ResourceWithValidateConfig = &clusterResource{}
 )
 
 func NewClusterResource() resource.Resource {
@@ -40,6 +45,10 @@ func (r *clusterResource) Schema(ctx context.Context, req resource.SchemaRequest
 	resp.Schema = ClusterResourceSchema(ctx)
 }
 
+func (r *clusterResource) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = common.ProjectResourceIdentitySchema("Cluster identifier")
+}
+
 func (r *clusterResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -57,22 +66,175 @@ func (r *clusterResource) Configure(_ context.Context, req resource.ConfigureReq
 	r.meta = meta
 }
 
+// ValidateConfig checks what can be checked without the API, the upgrade path
+// is validated against the API in ModifyPlan.
+func (r *clusterResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var model ClusterModel
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("spec"), &model.Spec)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if v := getVersionFromModel(ctx, &model); v != "" {
+		if _, err := version.NewVersion(v); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("spec").AtListIndex(0).AtName("version"),
+				"Invalid version",
+				fmt.Sprintf("Version %q is not a semantic version, e.g. 1.31.5", v),
+			)
+		}
+	}
+}
+
+func (r *clusterResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	resp.Diagnostics.Append(r.planDeletionProtection(ctx, req, resp)...)
+
+	// Nothing else to do on destroy.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.planLabelsAll(ctx, req, resp)...)
+	resp.Diagnostics.Append(r.meta.PlanDefaultProjectID(ctx, req, resp)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.validateVersionUpgradePlan(ctx, req, resp)...)
+}
+
+// planDeletionProtection refuses to plan the destruction or replacement of a
+// protected cluster.
+func (r *clusterResource) planDeletionProtection(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	replacedBy, d := common.PlannedReplacement(ctx, resp.Private)
+	diags.Append(d...)
+	if diags.HasError() || req.State.Raw.IsNull() || (!req.Plan.Raw.IsNull() && replacedBy == "") {
+		return diags
+	}
+
+	var state ClusterModel
+	diags.Append(req.State.Get(ctx, &state)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if replacedBy == "" {
+		diags.Append(common.DeletionProtectionToFrameworkDiagnostics(r.checkDeletionProtection("destroy", &state))...)
+		return diags
+	}
+	if err := r.checkDeletionProtection("replace", &state); err != nil {
+		diags.AddAttributeError(
+			path.Root("deletion_protection"),
+			"Deletion Protection",
+			fmt.Sprintf("Changing %s requires replacing the cluster. %s", replacedBy, err),
+		)
+	}
+	return diags
+}
+
+func (r *clusterResource) checkDeletionProtection(operation string, state *ClusterModel) error {
+	labels := state.LabelsAll
+	if labels.IsNull() || labels.IsUnknown() {
+		labels = state.Labels
+	}
+	return r.meta.CheckDeletionProtection(operation, "cluster", state.Name.ValueString(), state.DeletionProtection.ValueBool(), expandLabelsFromModel(labels))
+}
+
+// validateVersionUpgradePlan validates a change of spec.version against the
+// upgrades available for the existing cluster, so that an illegal upgrade
+// fails the plan instead of the apply. It is the only check against the API
+// during plan, with skip_remote_validation it is left to apply.
+func (r *clusterResource) validateVersionUpgradePlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.meta == nil || req.State.Raw.IsNull() {
+		return diags
+	}
+
+	var plan, state ClusterModel
+	diags.Append(resp.Plan.Get(ctx, &plan)...)
+	diags.Append(req.State.Get(ctx, &state)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	planVersion := getVersionFromModel(ctx, &plan)
+	if planVersion == "" || planVersion == getVersionFromModel(ctx, &state) {
+		return diags
+	}
+	if r.meta.SkipRemoteValidation {
+		diags.AddWarning(
+			"Remote validation skipped",
+			"skip_remote_validation is set, the version upgrade is validated against the MetaKube API during apply.",
+		)
+		return diags
+	}
+
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+	cluster, ok, err := common.MetakubeGetCluster(ctx, state.ProjectID.ValueString(), state.ID.ValueString(), r.meta)
+	if err != nil {
+		diags.AddError("Failed to get cluster", err.Error())
+		return diags
+	}
+	if ok {
+		diags.Append(metakubeResourceClusterValidateVersionUpgrade(ctx, state.ProjectID.ValueString(), planVersion, cluster, r.meta)...)
+	}
+	return diags
+}
+
+// planLabelsAll plans labels_all as the configured labels merged into the
+// provider's default labels.
+func (r *clusterResource) planLabelsAll(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.meta == nil {
+		return diags
+	}
+
+	var labels types.Map
+	diags.Append(req.Plan.GetAttribute(ctx, path.Root("labels"), &labels)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if labels.IsUnknown() {
+		diags.Append(resp.Plan.SetAttribute(ctx, path.Root("labels_all"), types.MapUnknown(types.StringType))...)
+		return diags
+	}
+
+	labelsAll, d := types.MapValueFrom(ctx, types.StringType, common.MergeDefaultLabels(r.meta.DefaultLabels, expandLabelsFromModel(labels)))
+	diags.Append(d...)
+	diags.Append(resp.Plan.SetAttribute(ctx, path.Root("labels_all"), labelsAll)...)
+	return diags
+}
+
 func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("create", "cluster"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan ClusterModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	creds, diags := credentialsFromConfig(ctx, req.Config)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.Diagnostics.Append(metakubeResourceClusterValidateClusterFields(ctx, &plan, r.meta, false)...)
+	resp.Diagnostics.Append(metakubeResourceClusterValidateClusterFields(ctx, &plan, creds, r.meta, false)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	dcname := plan.DCName.ValueString()
-	clusterSpec := metakubeResourceClusterExpandSpec(ctx, &plan, dcname, func(_ string) bool { return true })
-	clusterLabels := expandLabelsFromModel(plan.Labels)
+	clusterSpec := metakubeResourceClusterExpandSpec(ctx, &plan, dcname, creds, func(_ string) bool { return true })
+	clusterLabels := common.MergeDefaultLabels(r.meta.DefaultLabels, expandLabelsFromModel(plan.Labels))
 
 	createClusterSpec := &models.CreateClusterSpec{
 		Cluster: &models.Cluster{
@@ -149,6 +311,7 @@ func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest
 			fmt.Sprintf("Cluster '%s' is not ready: %v", result.Payload.ID, err),
 		)
 		resp.State.Set(ctx, &plan)
+		resp.Identity.Set(ctx, clusterIdentity(&plan))
 		return
 	}
 
@@ -159,9 +322,12 @@ func (r *clusterResource) Create(ctx context.Context, req resource.CreateRequest
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, clusterIdentity(&plan))...)
 }
 
 func (r *clusterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+
 	var state ClusterModel
 	diags := req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -181,14 +347,24 @@ func (r *clusterResource) Read(ctx context.Context, req resource.ReadRequest, re
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, clusterIdentity(&state))...)
 }
 
 func (r *clusterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("update", "cluster"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan, state ClusterModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
 	diags = req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
+	creds, diags := credentialsFromConfig(ctx, req.Config)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -207,23 +383,29 @@ func (r *clusterResource) Update(ctx context.Context, req resource.UpdateRequest
 
 	planVersion := getVersionFromModel(ctx, &plan)
 	stateVersion := getVersionFromModel(ctx, &state)
+	// Validate again, the plan may have skipped the check or the cluster may have changed since.
 	if planVersion != stateVersion {
-		r.meta.Log.Debugf("validating version change")
+		r.meta.Log.Debugf(ctx, "validating version change")
 		resp.Diagnostics.Append(metakubeResourceClusterValidateVersionUpgrade(ctx, projectID, planVersion, cluster, r.meta)...)
 	}
 
-	resp.Diagnostics.Append(metakubeResourceClusterValidateClusterFields(ctx, &plan, r.meta, true)...)
+	resp.Diagnostics.Append(metakubeResourceClusterValidateClusterFields(ctx, &plan, creds, r.meta, true)...)
 	resp.Diagnostics.Append(r.validateDatacenter(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	nameChanged := !plan.Name.Equal(state.Name)
-	labelsChanged := !plan.Labels.Equal(state.Labels)
+	labelsChanged := !plan.Labels.Equal(state.Labels) || !plan.LabelsAll.Equal(state.LabelsAll)
 	specChanged := !plan.Spec.Equal(state.Spec)
 
+	// Write-only credentials are only sent again when credentials_version changes.
+	if getCredentialsVersion(ctx, &plan).Equal(getCredentialsVersion(ctx, &state)) {
+		creds = nil
+	}
+
 	if nameChanged || labelsChanged || specChanged {
-		if err := r.sendPatchRequest(ctx, &plan, &state); err != nil {
+		if err := r.sendPatchRequest(ctx, &plan, &state, creds); err != nil {
 			resp.Diagnostics.AddError("Failed to patch cluster", err.Error())
 			return
 		}
@@ -251,18 +433,41 @@ func (r *clusterResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	if policy := getUpgradePolicyFromModel(ctx, &plan); policy != nil && policy.NodeDeployments.ValueString() == upgradePolicyFollow && planVersion != stateVersion {
+		if err := r.upgradeNodeDeployments(ctx, projectID, state.ID.ValueString(), planVersion, policy); err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to upgrade node deployments",
+				fmt.Sprintf("The control plane of cluster '%s' was upgraded to %s, but not all of its node deployments: %v", state.ID.ValueString(), planVersion, err),
+			)
+			return
+		}
+	}
+
 	plan.ID = state.ID
+	plannedStatus := plan.Status
 
 	resp.Diagnostics.Append(r.readClusterIntoModel(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	// The status planned from state has to be kept, the next refresh updates it.
+	if !plannedStatus.IsUnknown() {
+		plan.Status = plannedStatus
+	}
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, clusterIdentity(&plan))...)
 }
 
 func (r *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("delete", "cluster"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var state ClusterModel
 	diags := req.State.Get(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -270,6 +475,11 @@ func (r *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	resp.Diagnostics.Append(common.DeletionProtectionToFrameworkDiagnostics(r.checkDeletionProtection("delete", &state))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	projectID := state.ProjectID.ValueString()
 	clusterID := state.ID.ValueString()
 
@@ -335,7 +545,7 @@ func (r *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest
 			if err != nil {
 				if e, ok := err.(*project.GetClusterV2Default); ok {
 					if e.Code() == http.StatusNotFound {
-						r.meta.Log.Debugf("cluster '%s' has been destroyed, returned http code: %d", clusterID, e.Code())
+						r.meta.Log.Debugf(ctx, "cluster '%s' has been destroyed, returned http code: %d", clusterID, e.Code())
 						return
 					}
 					if e.Code() == http.StatusInternalServerError {
@@ -353,7 +563,7 @@ func (r *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest
 					return
 				}
 			} else {
-				r.meta.Log.Debugf("cluster '%s' deletion in progress, deletionTimestamp: %s, elapsed: %s",
+				r.meta.Log.Debugf(ctx, "cluster '%s' deletion in progress, deletionTimestamp: %s, elapsed: %s",
 					clusterID, result.Payload.DeletionTimestamp.String(), time.Since(deleteStartTime).Round(time.Second))
 			}
 		}
@@ -377,13 +587,38 @@ func (r *clusterResource) Delete(ctx context.Context, req resource.DeleteRequest
 	}
 }
 
+// ImportState accepts an identity or the legacy import IDs 'project_id:cluster_id'
+// and 'cluster_id'. The project is looked up when it is not given.
 func (r *clusterResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	parts := strings.Split(req.ID, ":")
+	var projectID, clusterID string
+	if req.ID == "" {
+		var identity common.ProjectResourceIdentityModel
+		resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		projectID = identity.ProjectID.ValueString()
+		clusterID = identity.ID.ValueString()
+	} else {
+		parts := strings.Split(req.ID, ":")
+		switch len(parts) {
+		case 1:
+			clusterID = parts[0]
+		case 2:
+			projectID = parts[0]
+			clusterID = parts[1]
+		default:
+			resp.Diagnostics.AddError(
+				"Invalid import ID",
+				"Please provide resource identifier in format 'project_id:cluster_id' or 'cluster_id'",
+			)
+			return
+		}
+	}
 
-	switch len(parts) {
-	case 1:
-		clusterID := parts[0]
-		projectID, err := common.MetakubeResourceClusterFindProjectID(ctx, clusterID, r.meta)
+	if projectID == "" {
+		var err error
+		projectID, err = common.MetakubeResourceClusterFindProjectID(ctx, clusterID, r.meta)
 		if err != nil {
 			resp.Diagnostics.AddError("Failed to find project", err.Error())
 			return
@@ -392,16 +627,16 @@ func (r *clusterResource) ImportState(ctx context.Context, req resource.ImportSt
 			resp.Diagnostics.AddError("Project not found", fmt.Sprintf("Could not find project for cluster '%s'", clusterID))
 			return
 		}
-		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), clusterID)...)
-		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), projectID)...)
-	case 2:
-		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), parts[0])...)
-		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[1])...)
-	default:
-		resp.Diagnostics.AddError(
-			"Invalid import ID",
-			"Please provide resource identifier in format 'project_id:cluster_id' or 'cluster_id'",
-		)
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), clusterID)...)
+}
+
+func clusterIdentity(model *ClusterModel) *common.ProjectResourceIdentityModel {
+	return &common.ProjectResourceIdentityModel{
+		ProjectID: model.ProjectID,
+		ID:        model.ID,
 	}
 }
 
@@ -420,18 +655,18 @@ func (r *clusterResource) readClusterIntoModel(ctx context.Context, model *Clust
 			model.ID = types.StringNull()
 			return diags
 		}
-		r.meta.Log.Debugf("found cluster in project '%s'", projectID)
+		r.meta.Log.Debugf(ctx, "found cluster in project '%s'", projectID)
 	}
 
 	p := project.NewGetClusterV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(model.ID.ValueString())
 	result, err := r.meta.Client.Project.GetClusterV2(p, r.meta.Auth)
 	if isNotFoundError(err) {
-		r.meta.Log.Infof("removing cluster '%s', could not find the resource", model.ID.ValueString())
+		r.meta.Log.Infof(ctx, "removing cluster '%s', could not find the resource", model.ID.ValueString())
 		model.ID = types.StringNull()
 		return diags
 	}
 	if err != nil {
-		r.meta.Log.Debugf("get cluster: %v", err)
+		r.meta.Log.Debugf(ctx, "get cluster: %v", err)
 		diags.AddError(
 			"Unable to get cluster",
 			fmt.Sprintf("Unable to get cluster '%s/%s': %s", projectID, model.ID.ValueString(), common.StringifyResponseError(err)),
@@ -441,27 +676,42 @@ func (r *clusterResource) readClusterIntoModel(ctx context.Context, model *Clust
 
 	model.ProjectID = types.StringValue(projectID)
 	model.DCName = types.StringValue(result.Payload.Spec.Cloud.DatacenterName)
+	// deletion_protection is not stored in MetaKube, e.g. imported clusters start unprotected.
+	if model.DeletionProtection.IsNull() {
+		model.DeletionProtection = types.BoolValue(false)
+	}
 	model.Name = types.StringValue(result.Payload.Name)
 
-	if len(result.Payload.Labels) > 0 {
-		labels := make(map[string]attr.Value)
-		for k, v := range result.Payload.Labels {
-			if !common.MetakubeResourceSystemLabelOrTag(k) {
-				labels[k] = types.StringValue(v)
-			}
-		}
-		labelsValue, d := types.MapValue(types.StringType, labels)
-		diags.Append(d...)
-		model.Labels = labelsValue
-	} else {
-		model.Labels = types.MapValueMust(types.StringType, map[string]attr.Value{})
-	}
+	// Labels set in the configuration are user labels even when they match an ignored prefix.
+	configured := expandLabelsFromModel(model.Labels)
+	labelsAll, systemLabels := r.meta.LabelFilter.WithConfigured(configured).SplitLabels(result.Payload.Labels)
+	labelsAllValue, d := types.MapValueFrom(ctx, types.StringType, labelsAll)
+	diags.Append(d...)
+	model.LabelsAll = labelsAllValue
+	systemLabelsValue, d := types.MapValueFrom(ctx, types.StringType, systemLabels)
+	diags.Append(d...)
+	model.SystemLabels = systemLabelsValue
+	// Labels only added from the provider's default_labels don't belong to the configuration.
+	labels := common.StripDefaultLabels(r.meta.DefaultLabels, labelsAll, configured)
+	labelsValue, d := types.MapValueFrom(ctx, types.StringType, labels)
+	diags.Append(d...)
+	model.Labels = labelsValue
 
 	diags.Append(metakubeResourceClusterFlattenSpec(ctx, model, result.Payload.Spec)...)
 
 	model.CreationTimestamp = types.StringValue(result.Payload.CreationTimestamp.String())
 	model.DeletionTimestamp = types.StringValue(result.Payload.DeletionTimestamp.String())
 
+	p1 := project.NewGetClusterHealthV2Params().WithContext(ctx).WithProjectID(projectID).WithClusterID(model.ID.ValueString())
+	var health *models.ClusterHealth
+	if res, err := r.meta.Client.Project.GetClusterHealthV2(p1, r.meta.Auth); err != nil {
+		// The health endpoint fails e.g. while the cluster is provisioning, that must not break refresh or import.
+		r.meta.Log.Debugf(ctx, "unable to get health of cluster '%s/%s', status.health is left empty: %s", projectID, model.ID.ValueString(), common.StringifyResponseError(err))
+	} else {
+		health = res.Payload
+	}
+	diags.Append(flattenClusterStatus(ctx, model, result.Payload.Status, health)...)
+
 	keys, err := r.metakubeClusterGetAssignedSSHKeys(ctx, projectID, model.ID.ValueString())
 	if err != nil {
 		diags.AddError("Failed to get SSH keys", err.Error())
@@ -477,7 +727,25 @@ func (r *clusterResource) readClusterIntoModel(ctx context.Context, model *Clust
 		model.SSHKeys = types.SetValueMust(types.StringType, []attr.Value{})
 	}
 
-	if conf, err := r.metakubeClusterUpdateKubeconfig(ctx, projectID, model.ID.ValueString()); err != nil {
+	diags.Append(r.readKubeconfigsIntoModel(ctx, projectID, model)...)
+
+	return diags
+}
+
+// readKubeconfigsIntoModel stores the kubeconfigs of the cluster in the model,
+// unless the provider is configured with skip_kubeconfigs.
+func (r *clusterResource) readKubeconfigsIntoModel(ctx context.Context, projectID string, model *ClusterModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.meta.SkipKubeconfigs {
+		model.KubeConfig = types.StringNull()
+		model.OIDCKubeConfig = types.StringNull()
+		model.KubeLoginKubeConfig = types.StringNull()
+		return diags
+	}
+
+	clusterID := model.ID.ValueString()
+	if conf, err := common.MetakubeGetKubeconfig(ctx, r.meta, projectID, clusterID, common.KubeconfigAdmin); err != nil {
 		diags.AddWarning("Could not get kubeconfig", fmt.Sprintf("could not update kubeconfig: %v", err))
 		model.KubeConfig = types.StringValue("")
 	} else {
@@ -485,14 +753,14 @@ func (r *clusterResource) readClusterIntoModel(ctx context.Context, model *Clust
 	}
 
 	if hasSyselevenAuth(ctx, model) {
-		if conf, err := r.metakubeClusterUpdateOIDCKubeconfig(ctx, projectID, model.ID.ValueString()); err != nil {
-			diags.AddWarning("Could not get OIDC kubeconfig", fmt.Sprintf("could not update OIDC kubeconfig: %s", common.StringifyResponseError(err)))
+		if conf, err := common.MetakubeGetKubeconfig(ctx, r.meta, projectID, clusterID, common.KubeconfigOIDC); err != nil {
+			diags.AddWarning("Could not get OIDC kubeconfig", fmt.Sprintf("could not update OIDC kubeconfig: %v", err))
 			model.OIDCKubeConfig = types.StringValue("")
 		} else {
 			model.OIDCKubeConfig = types.StringValue(conf)
 		}
 
-		if conf, err := r.metakubeClusterUpdateKubeloginKubeconfig(ctx, projectID, model.ID.ValueString()); err != nil {
+		if conf, err := common.MetakubeGetKubeconfig(ctx, r.meta, projectID, clusterID, common.KubeconfigKubeLogin); err != nil {
 			diags.AddWarning("Could not get kubelogin kubeconfig", fmt.Sprintf("could not update kubelogin kubeconfig: %v", err))
 			model.KubeLoginKubeConfig = types.StringValue("")
 		} else {
@@ -506,42 +774,6 @@ func (r *clusterResource) readClusterIntoModel(ctx context.Context, model *Clust
 	return diags
 }
 
-func (r *clusterResource) metakubeClusterUpdateKubeconfig(ctx context.Context, projectID, clusterID string) (string, error) {
-	kubeConfigParams := project.NewGetClusterKubeconfigV2Params()
-	kubeConfigParams.SetContext(ctx)
-	kubeConfigParams.SetProjectID(projectID)
-	kubeConfigParams.SetClusterID(clusterID)
-	ret, err := r.meta.Client.Project.GetClusterKubeconfigV2(kubeConfigParams, r.meta.Auth)
-	if err != nil {
-		return "", fmt.Errorf("failed to get kube_config: %s", common.StringifyResponseError(err))
-	}
-	return string(ret.Payload), nil
-}
-
-func (r *clusterResource) metakubeClusterUpdateOIDCKubeconfig(ctx context.Context, projectID, clusterID string) (string, error) {
-	kubeConfigParams := project.NewGetOidcClusterKubeconfigV2Params()
-	kubeConfigParams.SetContext(ctx)
-	kubeConfigParams.SetProjectID(projectID)
-	kubeConfigParams.SetClusterID(clusterID)
-	ret, err := r.meta.Client.Project.GetOidcClusterKubeconfigV2(kubeConfigParams, r.meta.Auth)
-	if err != nil {
-		return "", fmt.Errorf("failed to get oidc_kube_config: %s", common.StringifyResponseError(err))
-	}
-	return string(ret.Payload), nil
-}
-
-func (r *clusterResource) metakubeClusterUpdateKubeloginKubeconfig(ctx context.Context, projectID, clusterID string) (string, error) {
-	kubeConfigParams := project.NewGetKubeLoginClusterKubeconfigV2Params()
-	kubeConfigParams.SetContext(ctx)
-	kubeConfigParams.SetProjectID(projectID)
-	kubeConfigParams.SetClusterID(clusterID)
-	ret, err := r.meta.Client.Project.GetKubeLoginClusterKubeconfigV2(kubeConfigParams, r.meta.Auth)
-	if err != nil {
-		return "", fmt.Errorf("failed to get kube_login_kube_config: %s", common.StringifyResponseError(err))
-	}
-	return string(ret.Payload), nil
-}
-
 func (r *clusterResource) metakubeClusterGetAssignedSSHKeys(ctx context.Context, projectID, clusterID string) ([]string, error) {
 	p := project.NewListSSHKeysAssignedToClusterV2Params().WithProjectID(projectID).WithClusterID(clusterID).WithContext(ctx)
 	ret, err := r.meta.Client.Project.ListSSHKeysAssignedToClusterV2(p, r.meta.Auth)
@@ -607,7 +839,7 @@ func (r *clusterResource) validateDatacenter(ctx context.Context, model *Cluster
 	return diags
 }
 
-func (r *clusterResource) sendPatchRequest(ctx context.Context, plan, state *ClusterModel) error {
+func (r *clusterResource) sendPatchRequest(ctx context.Context, plan, state *ClusterModel, creds *clusterCredentials) error {
 	projectID := plan.ProjectID.ValueString()
 	clusterID := state.ID.ValueString()
 
@@ -617,8 +849,8 @@ func (r *clusterResource) sendPatchRequest(ctx context.Context, plan, state *Clu
 	p.SetClusterID(clusterID)
 
 	name := plan.Name.ValueString()
-	labels := getLabelsChange(ctx, plan, state)
-	clusterSpec := metakubeResourceClusterExpandSpec(ctx, plan, plan.DCName.ValueString(), func(_ string) bool { return true })
+	labels := getLabelsChange(r.meta.DefaultLabels, plan, state)
+	clusterSpec := metakubeResourceClusterExpandSpec(ctx, plan, plan.DCName.ValueString(), creds, func(_ string) bool { return true })
 
 	p.SetPatch(map[string]interface{}{
 		"name":   name,
@@ -691,6 +923,17 @@ func (r *clusterResource) updateClusterSSHKeys(ctx context.Context, plan, state
 	return r.assignSSHKeysToCluster(projectID, clusterID, toAssign)
 }
 
+// credentialsFromConfig returns the write-only credentials, which are only
+// available in the configuration.
+func credentialsFromConfig(ctx context.Context, config tfsdk.Config) (*clusterCredentials, diag.Diagnostics) {
+	var model ClusterModel
+	diags := config.Get(ctx, &model)
+	if diags.HasError() {
+		return nil, diags
+	}
+	return getCredentialsFromModel(ctx, &model), diags
+}
+
 func isNotFoundError(err error) bool {
 	if err == nil {
 		return false
@@ -797,9 +1040,13 @@ func expandSSHKeysFromModel(sshkeys types.Set) []string {
 	return result
 }
 
-func getLabelsChange(ctx context.Context, plan, state *ClusterModel) map[string]interface{} {
-	oldLabels := expandLabelsFromModel(state.Labels)
-	newLabels := expandLabelsFromModel(plan.Labels)
+func getLabelsChange(defaults map[string]string, plan, state *ClusterModel) map[string]interface{} {
+	oldLabels := expandLabelsFromModel(state.LabelsAll)
+	if state.LabelsAll.IsNull() || state.LabelsAll.IsUnknown() {
+		// State written before labels_all existed.
+		oldLabels = expandLabelsFromModel(state.Labels)
+	}
+	newLabels := common.MergeDefaultLabels(defaults, expandLabelsFromModel(plan.Labels))
 
 	result := make(map[string]interface{})
 	for k, v := range newLabels {
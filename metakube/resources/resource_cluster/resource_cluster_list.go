@@ -0,0 +1,160 @@
+package resource_cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/go-metakube/client/project"
+	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+var (
+	_ list.ListResource              = &clusterResource{}
+	_ list.ListResourceWithConfigure = &clusterResource{}
+)
+
+func NewClusterListResource() list.ListResource {
+	return &clusterResource{}
+}
+
+type clusterListModel struct {
+	ProjectID types.String `tfsdk:"project_id"`
+	Name      types.String `tfsdk:"name"`
+	DCName    types.String `tfsdk:"dc_name"`
+	Labels    types.Map    `tfsdk:"labels"`
+}
+
+func (r *clusterResource) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists clusters, for example to generate import blocks with terraform query",
+		Attributes: map[string]schema.Attribute{
+			"project_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Project to list clusters of. Defaults to the project of the provider profile, otherwise clusters of all projects are listed",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list clusters with this name",
+			},
+			"dc_name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list clusters in this datacenter",
+			},
+			"labels": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Only list clusters having all of these labels",
+			},
+		},
+	}
+}
+
+func (r *clusterResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	if r.meta == nil {
+		stream.Results = list.ListResultsStreamDiagnostics(common.UnconfiguredProviderDiagnostics())
+		return
+	}
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemCluster)
+
+	var config clusterListModel
+	diags := req.Config.Get(ctx, &config)
+	labels := make(map[string]string)
+	if !config.Labels.IsNull() {
+		diags.Append(config.Labels.ElementsAs(ctx, &labels, false)...)
+	}
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	projectIDs, err := common.MetakubeListProjectIDs(ctx, config.ProjectID.ValueString(), r.meta)
+	if err != nil {
+		diags.AddError("Unable to list projects", err.Error())
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, projectID := range projectIDs {
+			p := project.NewListClustersV2Params().WithContext(ctx).WithProjectID(projectID)
+			res, err := r.meta.Client.Project.ListClustersV2(p, r.meta.Auth)
+			if err != nil {
+				result := req.NewListResult(ctx)
+				result.Diagnostics.AddError(
+					"Unable to list clusters",
+					fmt.Sprintf("Unable to list clusters of project '%s': %s", projectID, common.StringifyResponseError(err)),
+				)
+				push(result)
+				return
+			}
+
+			for _, cluster := range res.Payload {
+				if !clusterMatchesListConfig(cluster, &config, labels) {
+					continue
+				}
+				if !push(r.clusterListResult(ctx, req, projectID, cluster)) {
+					return
+				}
+				count++
+				if req.Limit > 0 && count >= req.Limit {
+					return
+				}
+			}
+		}
+	}
+}
+
+func clusterMatchesListConfig(cluster *models.Cluster, config *clusterListModel, labels map[string]string) bool {
+	if cluster == nil {
+		return false
+	}
+	if name := config.Name.ValueString(); name != "" && cluster.Name != name {
+		return false
+	}
+	if dcName := config.DCName.ValueString(); dcName != "" && (cluster.Spec == nil || cluster.Spec.Cloud == nil || cluster.Spec.Cloud.DatacenterName != dcName) {
+		return false
+	}
+	for k, v := range labels {
+		if l, ok := cluster.Labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// clusterListResult returns the identity of a listed cluster and, when
+// requested, reads the full resource the same way an import does.
+func (r *clusterResource) clusterListResult(ctx context.Context, req list.ListRequest, projectID string, cluster *models.Cluster) list.ListResult {
+	result := req.NewListResult(ctx)
+	result.DisplayName = cluster.Name
+
+	result.Diagnostics.Append(result.Identity.Set(ctx, &common.ProjectResourceIdentityModel{
+		ProjectID: types.StringValue(projectID),
+		ID:        types.StringValue(cluster.ID),
+	})...)
+	if !req.IncludeResource || result.Diagnostics.HasError() {
+		return result
+	}
+
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("id"), cluster.ID)...)
+	var model ClusterModel
+	result.Diagnostics.Append(result.Resource.Get(ctx, &model)...)
+	if result.Diagnostics.HasError() {
+		return result
+	}
+
+	result.Diagnostics.Append(r.readClusterIntoModel(ctx, &model)...)
+	if result.Diagnostics.HasError() {
+		return result
+	}
+	result.Diagnostics.Append(result.Resource.Set(ctx, &model)...)
+
+	return result
+}
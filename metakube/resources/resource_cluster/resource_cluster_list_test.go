@@ -0,0 +1,70 @@
+package resource_cluster
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/go-metakube/models"
+)
+
+func TestClusterMatchesListConfig(t *testing.T) {
+	cluster := &models.Cluster{
+		ID:     "abc",
+		Name:   "prod",
+		Labels: map[string]string{"team": "a", "env": "prod"},
+		Spec: &models.ClusterSpec{
+			Cloud: &models.CloudSpec{DatacenterName: "dbl1"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		config   clusterListModel
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:     "no filter",
+			expected: true,
+		},
+		{
+			name:     "name matches",
+			config:   clusterListModel{Name: types.StringValue("prod")},
+			expected: true,
+		},
+		{
+			name:     "name differs",
+			config:   clusterListModel{Name: types.StringValue("dev")},
+			expected: false,
+		},
+		{
+			name:     "datacenter differs",
+			config:   clusterListModel{DCName: types.StringValue("cbk1")},
+			expected: false,
+		},
+		{
+			name:     "labels match",
+			config:   clusterListModel{DCName: types.StringValue("dbl1")},
+			labels:   map[string]string{"team": "a"},
+			expected: true,
+		},
+		{
+			name:     "label value differs",
+			labels:   map[string]string{"team": "b"},
+			expected: false,
+		},
+		{
+			name:     "label missing",
+			labels:   map[string]string{"owner": "a"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := clusterMatchesListConfig(cluster, &tc.config, tc.labels); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
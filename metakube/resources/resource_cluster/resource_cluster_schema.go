@@ -8,12 +8,14 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -22,6 +24,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
 )
 
 type durationValidator struct{}
@@ -56,9 +59,11 @@ func DurationValidator() validator.String {
 
 // envDefaultPlanModifier is a plan modifier that sets a default value from an environment variable.
 // When diffSuppress is enabled, it also uses the prior state value when the config value is null/empty.
+// When the sibling write-only attribute is set, the value is planned as null instead.
 type envDefaultPlanModifier struct {
 	envVar       string
 	diffSuppress bool
+	writeOnly    string
 }
 
 func (m envDefaultPlanModifier) Description(ctx context.Context) string {
@@ -77,6 +82,15 @@ func (m envDefaultPlanModifier) PlanModifyString(ctx context.Context, req planmo
 		return
 	}
 
+	if m.writeOnly != "" {
+		var writeOnly types.String
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, req.Path.ParentPath().AtName(m.writeOnly), &writeOnly)...)
+		if !writeOnly.IsNull() {
+			resp.PlanValue = types.StringNull()
+			return
+		}
+	}
+
 	if m.diffSuppress && !req.StateValue.IsNull() && req.StateValue.ValueString() != "" {
 		resp.PlanValue = req.StateValue
 		return
@@ -95,6 +109,13 @@ func EnvDefaultWithDiffSuppress(envVar string) planmodifier.String {
 	return envDefaultPlanModifier{envVar: envVar, diffSuppress: true}
 }
 
+// EnvDefaultUnlessWriteOnly works like EnvDefaultWithDiffSuppress, but plans
+// null while the write-only alternative of the attribute is set, so the
+// credential does not end up in the state from the environment or prior state.
+func EnvDefaultUnlessWriteOnly(envVar, writeOnlyAttribute string) planmodifier.String {
+	return envDefaultPlanModifier{envVar: envVar, diffSuppress: true, writeOnly: writeOnlyAttribute}
+}
+
 type boolDiffSuppressPlanModifier struct{}
 
 func (m boolDiffSuppressPlanModifier) Description(ctx context.Context) string {
@@ -136,6 +157,38 @@ func CNIPluginDiffSuppress() planmodifier.Object {
 	return cniPluginPlanModifier{}
 }
 
+type statusPlanModifier struct{}
+
+func (m statusPlanModifier) Description(ctx context.Context) string {
+	return "Keeps the status from state unless the Kubernetes version changes"
+}
+
+func (m statusPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+// PlanModifyObject keeps status out of the diff of updates that don't upgrade
+// the cluster, Update stores the planned status then and the next refresh
+// reads the current one.
+func (m statusPlanModifier) PlanModifyObject(ctx context.Context, req planmodifier.ObjectRequest, resp *planmodifier.ObjectResponse) {
+	if req.StateValue.IsNull() || !req.PlanValue.IsUnknown() {
+		return
+	}
+
+	versionPath := fwpath.Root("spec").AtListIndex(0).AtName("version")
+	var planVersion, stateVersion types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, versionPath, &planVersion)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, versionPath, &stateVersion)...)
+	if resp.Diagnostics.HasError() || !planVersion.Equal(stateVersion) {
+		return
+	}
+	resp.PlanValue = req.StateValue
+}
+
+func StatusUseStateUnlessVersionChanges() planmodifier.Object {
+	return statusPlanModifier{}
+}
+
 func ClusterResourceSchema(ctx context.Context) schema.Schema {
 	return schema.Schema{
 		Description: "Cluster resource in MetaKube",
@@ -152,6 +205,43 @@ func ClusterResourceSchema(ctx context.Context) schema.Schema {
 					Blocks:     metakubeResourceClusterSpecBlocks(),
 				},
 			},
+			"upgrade_policy": schema.ListNestedBlock{
+				Description: "How a change of spec.version is rolled out to the node deployments of the cluster",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"node_deployments": schema.StringAttribute{
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString(upgradePolicyManual),
+							Description: "With follow, the kubelet of every node deployment is upgraded after the control plane, to the newest version the control plane supports. With manual, node deployments are upgraded separately",
+							Validators: []validator.String{
+								stringvalidator.OneOf(upgradePolicyFollow, upgradePolicyManual),
+							},
+						},
+						"max_parallel": schema.Int64Attribute{
+							Optional:    true,
+							Computed:    true,
+							Default:     int64default.StaticInt64(1),
+							Description: "Number of node deployments upgraded at the same time",
+							Validators: []validator.Int64{
+								int64validator.AtLeast(1),
+							},
+						},
+						"node_deployment_timeout": schema.StringAttribute{
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString("20m"),
+							Description: "How long to wait for each node deployment to roll out its nodes",
+							Validators: []validator.String{
+								DurationValidator(),
+							},
+						},
+					},
+				},
+			},
 		},
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -162,17 +252,19 @@ func ClusterResourceSchema(ctx context.Context) schema.Schema {
 				},
 			},
 			"project_id": schema.StringAttribute{
-				Required:    true,
-				Description: "Reference project identifier",
+				Optional:    true,
+				Computed:    true,
+				Description: "Reference project identifier, defaults to the project of the selected provider profile",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+					common.RequiresReplace(),
 				},
 			},
 			"dc_name": schema.StringAttribute{
 				Required:    true,
 				Description: "Data center name",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"name": schema.StringAttribute{
@@ -186,6 +278,22 @@ func ClusterResourceSchema(ctx context.Context) schema.Schema {
 				Description: "Labels added to cluster",
 				Default:     mapdefault.StaticValue(types.MapValueMust(types.StringType, map[string]attr.Value{})),
 			},
+			"labels_all": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Labels of the cluster, including the provider's default_labels",
+			},
+			"system_labels": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Labels managed by MetaKube, selected by the provider's ignore_label_prefixes and ignore_label_keys",
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Refuse to destroy or replace the cluster while set. Also implied by the provider's protect_labels",
+				Default:     booldefault.StaticBool(false),
+			},
 			"sshkeys": schema.SetAttribute{
 				Optional:    true,
 				Computed:    true,
@@ -204,16 +312,51 @@ func ClusterResourceSchema(ctx context.Context) schema.Schema {
 			"kube_config": schema.StringAttribute{
 				Sensitive:   true,
 				Computed:    true,
-				Description: "Kubeconfig for the cluster",
+				Description: "Kubeconfig for the cluster, null when the provider sets skip_kubeconfigs",
 			},
 			"oidc_kube_config": schema.StringAttribute{
 				Sensitive:   true,
 				Computed:    true,
-				Description: "OIDC Kubeconfig for the cluster",
+				Description: "OIDC Kubeconfig for the cluster, null when the provider sets skip_kubeconfigs",
 			},
 			"kube_login_kube_config": schema.StringAttribute{
+				Sensitive:   true,
+				Computed:    true,
+				Description: "Kubelogin Kubeconfig for the cluster, null when the provider sets skip_kubeconfigs",
+			},
+			"status": schema.SingleNestedAttribute{
 				Computed:    true,
-				Description: "Kubelogin Kubeconfig for the cluster",
+				Description: "Current state of the cluster as reported by MetaKube",
+				PlanModifiers: []planmodifier.Object{
+					StatusUseStateUnlessVersionChanges(),
+				},
+				Attributes: map[string]schema.Attribute{
+					"version": schema.StringAttribute{
+						Computed:    true,
+						Description: "Version the control plane runs, differs from spec.version while an upgrade is in progress",
+					},
+					"url": schema.StringAttribute{
+						Computed:    true,
+						Description: "URL of the API server",
+					},
+					"healthy": schema.BoolAttribute{
+						Computed:    true,
+						Description: "Whether all components of the cluster are up",
+					},
+					"health": schema.SingleNestedAttribute{
+						Computed:    true,
+						Description: "Health of each component of the cluster: up, down or provisioning",
+						Attributes: map[string]schema.Attribute{
+							"apiserver":                       schema.StringAttribute{Computed: true, Description: "Health of the API server"},
+							"etcd":                            schema.StringAttribute{Computed: true, Description: "Health of etcd"},
+							"controller":                      schema.StringAttribute{Computed: true, Description: "Health of the controller manager"},
+							"scheduler":                       schema.StringAttribute{Computed: true, Description: "Health of the scheduler"},
+							"machine_controller":              schema.StringAttribute{Computed: true, Description: "Health of the machine controller"},
+							"cloud_provider_infrastructure":   schema.StringAttribute{Computed: true, Description: "Health of the cloud provider infrastructure"},
+							"user_cluster_controller_manager": schema.StringAttribute{Computed: true, Description: "Health of the user cluster controller manager"},
+						},
+					},
+				},
 			},
 		},
 	}
@@ -258,7 +401,7 @@ func metakubeResourceClusterSpecAttributes() map[string]schema.Attribute {
 			Computed:    true,
 			Description: "Internal IP range for ClusterIP Services",
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				common.RequiresReplace(),
 			},
 		},
 		"pods_cidr": schema.StringAttribute{
@@ -266,7 +409,7 @@ func metakubeResourceClusterSpecAttributes() map[string]schema.Attribute {
 			Computed:    true,
 			Description: "Internal IP range for Pods",
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				common.RequiresReplace(),
 			},
 		},
 		"ip_family": schema.StringAttribute{
@@ -333,6 +476,12 @@ func metakubeResourceClusterSpecBlocks() map[string]schema.Block {
 				listvalidator.SizeAtMost(1),
 			},
 			NestedObject: schema.NestedBlockObject{
+				Attributes: map[string]schema.Attribute{
+					"credentials_version": schema.Int64Attribute{
+						Optional:    true,
+						Description: "Change this value to send password_wo, secret_wo or secret_access_key_wo to the API again, e.g. after rotating them. Write-only credentials are only sent when the cluster is created and when this value changes",
+					},
+				},
 				Blocks: map[string]schema.Block{
 					"aws": schema.ListNestedBlock{
 						Description: "AWS cluster specification",
@@ -397,19 +546,33 @@ func metakubeResourceClusterAWSCloudSpecFields() schema.NestedBlockObject {
 				},
 				Description: "Access key identifier",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"secret_access_key": schema.StringAttribute{
-				Required:  true,
+				Optional:  true,
 				Sensitive: true,
 				Validators: []validator.String{
 					stringvalidator.LengthAtLeast(1),
+					stringvalidator.ExactlyOneOf(fwpath.MatchRelative().AtParent().AtName("secret_access_key_wo")),
 				},
 				Description: "Secret access key",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.MarkReplacementString(stringplanmodifier.RequiresReplaceIf(
+						secretAccessKeyChanged,
+						"Changing the secret access key requires replacement, unless it is moved to secret_access_key_wo.",
+						"Changing the secret access key requires replacement, unless it is moved to secret_access_key_wo.",
+					)),
+				},
+			},
+			"secret_access_key_wo": schema.StringAttribute{
+				Optional:  true,
+				Sensitive: true,
+				WriteOnly: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
 				},
+				Description: "Secret access key, not stored in the state. Requires Terraform 1.11 or later",
 			},
 			"vpc_id": schema.StringAttribute{
 				Required: true,
@@ -418,35 +581,35 @@ func metakubeResourceClusterAWSCloudSpecFields() schema.NestedBlockObject {
 				},
 				Description: "Virtual private cloud identifier",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"security_group_id": schema.StringAttribute{
 				Optional:    true,
 				Description: "Security group identifier",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"route_table_id": schema.StringAttribute{
 				Optional:    true,
 				Description: "Route table identifier",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"instance_profile_name": schema.StringAttribute{
 				Optional:    true,
 				Description: "Instance profile name",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"role_arn": schema.StringAttribute{
 				Optional:    true,
 				Description: "The IAM role the control plane will use over assume-role",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"openstack_billing_tenant": schema.StringAttribute{
@@ -463,6 +626,11 @@ func metakubeResourceClusterAWSCloudSpecFields() schema.NestedBlockObject {
 	}
 }
 
+// secretAccessKeyChanged allows moving secret_access_key to secret_access_key_wo without replacing the cluster.
+func secretAccessKeyChanged(_ context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	resp.RequiresReplace = !req.PlanValue.IsNull()
+}
+
 func metakubeResourceClusterOpenstackCloudSpecFields() schema.NestedBlockObject {
 	return schema.NestedBlockObject{
 		Attributes: map[string]schema.Attribute{
@@ -471,7 +639,7 @@ func metakubeResourceClusterOpenstackCloudSpecFields() schema.NestedBlockObject
 				Optional:    true,
 				Description: "The floating ip pool used by all worker nodes to receive a public ip",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"security_group": schema.StringAttribute{
@@ -479,7 +647,7 @@ func metakubeResourceClusterOpenstackCloudSpecFields() schema.NestedBlockObject
 				Optional:    true,
 				Description: "When specified, all worker nodes will be attached to this security group. If not specified, a security group will be created",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"network": schema.StringAttribute{
@@ -487,7 +655,7 @@ func metakubeResourceClusterOpenstackCloudSpecFields() schema.NestedBlockObject
 				Optional:    true,
 				Description: "When specified, all worker nodes will be attached to this network. If not specified, a network, subnet & router will be created.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 			},
 			"subnet_id": schema.StringAttribute{
@@ -495,7 +663,7 @@ func metakubeResourceClusterOpenstackCloudSpecFields() schema.NestedBlockObject
 				Optional:    true,
 				Description: "When specified, all worker nodes will be attached to this subnet of specified network. If not specified, a network, subnet & router will be created.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 				Validators: []validator.String{
 					stringvalidator.AlsoRequires(fwpath.MatchRoot("spec").AtListIndex(0).AtName("cloud").AtListIndex(0).AtName("openstack").AtListIndex(0).AtName("network")),
@@ -505,7 +673,7 @@ func metakubeResourceClusterOpenstackCloudSpecFields() schema.NestedBlockObject
 				Computed: true,
 				Optional: true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					common.RequiresReplace(),
 				},
 				Description: "Change this to configure a different internal IP range for Nodes. Default: 192.168.1.0/24",
 			},
@@ -571,10 +739,19 @@ func metakubeResourceClusterOpenstackCloudSpecUserCredentialsFields() map[string
 			Optional:    true,
 			Sensitive:   true,
 			Description: "The openstack account's password",
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(fwpath.MatchRelative().AtParent().AtName("password_wo")),
+			},
 			PlanModifiers: []planmodifier.String{
-				EnvDefaultWithDiffSuppress("OS_PASSWORD"),
+				EnvDefaultUnlessWriteOnly("OS_PASSWORD", "password_wo"),
 			},
 		},
+		"password_wo": schema.StringAttribute{
+			Optional:    true,
+			Sensitive:   true,
+			WriteOnly:   true,
+			Description: "The openstack account's password, not stored in the state. Requires Terraform 1.11 or later",
+		},
 	}
 }
 
@@ -591,10 +768,19 @@ func metakubeResourceClusterOpenstackCloudSpecApplicationCredentialsFields() map
 			Optional:    true,
 			Sensitive:   true,
 			Description: "Openstack application credentials secret",
+			Validators: []validator.String{
+				stringvalidator.ConflictsWith(fwpath.MatchRelative().AtParent().AtName("secret_wo")),
+			},
 			PlanModifiers: []planmodifier.String{
-				EnvDefaultWithDiffSuppress("OS_APPLICATION_CREDENTIAL_SECRET"),
+				EnvDefaultUnlessWriteOnly("OS_APPLICATION_CREDENTIAL_SECRET", "secret_wo"),
 			},
 		},
+		"secret_wo": schema.StringAttribute{
+			Optional:    true,
+			Sensitive:   true,
+			WriteOnly:   true,
+			Description: "Openstack application credentials secret, not stored in the state. Requires Terraform 1.11 or later",
+		},
 	}
 }
 
@@ -605,13 +791,18 @@ type ClusterModel struct {
 	DCName              types.String   `tfsdk:"dc_name"`
 	Name                types.String   `tfsdk:"name"`
 	Labels              types.Map      `tfsdk:"labels"`
+	LabelsAll           types.Map      `tfsdk:"labels_all"`
+	SystemLabels        types.Map      `tfsdk:"system_labels"`
 	SSHKeys             types.Set      `tfsdk:"sshkeys"`
-	Spec                types.List     `tfsdk:"spec"` // []ClusterSpecModel
+	DeletionProtection  types.Bool     `tfsdk:"deletion_protection"`
+	Spec                types.List     `tfsdk:"spec"`           // []ClusterSpecModel
+	UpgradePolicy       types.List     `tfsdk:"upgrade_policy"` // []UpgradePolicyModel
 	CreationTimestamp   types.String   `tfsdk:"creation_timestamp"`
 	DeletionTimestamp   types.String   `tfsdk:"deletion_timestamp"`
 	KubeConfig          types.String   `tfsdk:"kube_config"`
 	OIDCKubeConfig      types.String   `tfsdk:"oidc_kube_config"`
 	KubeLoginKubeConfig types.String   `tfsdk:"kube_login_kube_config"`
+	Status              types.Object   `tfsdk:"status"` // ClusterStatusModel
 	Timeouts            timeouts.Value `tfsdk:"timeouts"`
 }
 
@@ -631,6 +822,38 @@ type ClusterSpecModel struct {
 	SyselevenAuth     types.List   `tfsdk:"syseleven_auth"` // []SyselevenAuthModel
 }
 
+// ClusterStatusModel represents the computed status attribute.
+type ClusterStatusModel struct {
+	Version types.String `tfsdk:"version"`
+	URL     types.String `tfsdk:"url"`
+	Healthy types.Bool   `tfsdk:"healthy"`
+	Health  types.Object `tfsdk:"health"` // ClusterHealthModel
+}
+
+// ClusterHealthModel represents status.health.
+type ClusterHealthModel struct {
+	Apiserver                    types.String `tfsdk:"apiserver"`
+	Etcd                         types.String `tfsdk:"etcd"`
+	Controller                   types.String `tfsdk:"controller"`
+	Scheduler                    types.String `tfsdk:"scheduler"`
+	MachineController            types.String `tfsdk:"machine_controller"`
+	CloudProviderInfrastructure  types.String `tfsdk:"cloud_provider_infrastructure"`
+	UserClusterControllerManager types.String `tfsdk:"user_cluster_controller_manager"`
+}
+
+// Values of upgrade_policy.node_deployments.
+const (
+	upgradePolicyFollow = "follow"
+	upgradePolicyManual = "manual"
+)
+
+// UpgradePolicyModel represents the upgrade_policy block.
+type UpgradePolicyModel struct {
+	NodeDeployments       types.String `tfsdk:"node_deployments"`
+	MaxParallel           types.Int64  `tfsdk:"max_parallel"`
+	NodeDeploymentTimeout types.String `tfsdk:"node_deployment_timeout"`
+}
+
 // UpdateWindowModel represents the update_window block.
 type UpdateWindowModel struct {
 	Start  types.String `tfsdk:"start"`
@@ -650,14 +873,16 @@ type SyselevenAuthModel struct {
 
 // ClusterCloudSpecModel represents the cloud block.
 type ClusterCloudSpecModel struct {
-	AWS       types.List `tfsdk:"aws"`       // []AWSCloudSpecModel
-	Openstack types.List `tfsdk:"openstack"` // []OpenstackCloudSpecModel
+	CredentialsVersion types.Int64 `tfsdk:"credentials_version"`
+	AWS                types.List  `tfsdk:"aws"`       // []AWSCloudSpecModel
+	Openstack          types.List  `tfsdk:"openstack"` // []OpenstackCloudSpecModel
 }
 
 // AWSCloudSpecModel represents the AWS cloud specification.
 type AWSCloudSpecModel struct {
 	AccessKeyID            types.String `tfsdk:"access_key_id"`
 	SecretAccessKey        types.String `tfsdk:"secret_access_key"`
+	SecretAccessKeyWO      types.String `tfsdk:"secret_access_key_wo"`
 	VPCID                  types.String `tfsdk:"vpc_id"`
 	SecurityGroupID        types.String `tfsdk:"security_group_id"`
 	RouteTableID           types.String `tfsdk:"route_table_id"`
@@ -684,12 +909,14 @@ type OpenstackUserCredentialsModel struct {
 	ProjectName types.String `tfsdk:"project_name"`
 	Username    types.String `tfsdk:"username"`
 	Password    types.String `tfsdk:"password"`
+	PasswordWO  types.String `tfsdk:"password_wo"`
 }
 
 // OpenstackApplicationCredentialsModel represents OpenStack application credentials.
 type OpenstackApplicationCredentialsModel struct {
-	ID     types.String `tfsdk:"id"`
-	Secret types.String `tfsdk:"secret"`
+	ID       types.String `tfsdk:"id"`
+	Secret   types.String `tfsdk:"secret"`
+	SecretWO types.String `tfsdk:"secret_wo"`
 }
 
 // Attribute type helper functions
@@ -711,6 +938,27 @@ func clusterSpecAttrTypes() map[string]attr.Type {
 	}
 }
 
+func clusterStatusAttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"version": types.StringType,
+		"url":     types.StringType,
+		"healthy": types.BoolType,
+		"health":  types.ObjectType{AttrTypes: clusterHealthAttrTypes()},
+	}
+}
+
+func clusterHealthAttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"apiserver":                       types.StringType,
+		"etcd":                            types.StringType,
+		"controller":                      types.StringType,
+		"scheduler":                       types.StringType,
+		"machine_controller":              types.StringType,
+		"cloud_provider_infrastructure":   types.StringType,
+		"user_cluster_controller_manager": types.StringType,
+	}
+}
+
 func updateWindowAttrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
 		"start":  types.StringType,
@@ -733,8 +981,9 @@ func syselevenAuthAttrTypes() map[string]attr.Type {
 
 func clusterCloudSpecAttrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
-		"aws":       types.ListType{ElemType: types.ObjectType{AttrTypes: awsCloudSpecAttrTypes()}},
-		"openstack": types.ListType{ElemType: types.ObjectType{AttrTypes: openstackCloudSpecAttrTypes()}},
+		"credentials_version": types.Int64Type,
+		"aws":                 types.ListType{ElemType: types.ObjectType{AttrTypes: awsCloudSpecAttrTypes()}},
+		"openstack":           types.ListType{ElemType: types.ObjectType{AttrTypes: openstackCloudSpecAttrTypes()}},
 	}
 }
 
@@ -742,6 +991,7 @@ func awsCloudSpecAttrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
 		"access_key_id":            types.StringType,
 		"secret_access_key":        types.StringType,
+		"secret_access_key_wo":     types.StringType,
 		"vpc_id":                   types.StringType,
 		"security_group_id":        types.StringType,
 		"route_table_id":           types.StringType,
@@ -770,12 +1020,14 @@ func openstackUserCredentialsAttrTypes() map[string]attr.Type {
 		"project_name": types.StringType,
 		"username":     types.StringType,
 		"password":     types.StringType,
+		"password_wo":  types.StringType,
 	}
 }
 
 func openstackApplicationCredentialsAttrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
-		"id":     types.StringType,
-		"secret": types.StringType,
+		"id":        types.StringType,
+		"secret":    types.StringType,
+		"secret_wo": types.StringType,
 	}
 }
@@ -8,14 +8,16 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
 	"k8s.io/utils/ptr"
 )
 
 // clusterPreserveValues holds values that need to be preserved during flatten operations
 // because the API doesn't return sensitive data or to maintain consistency with planned state
 type clusterPreserveValues struct {
-	aws       *models.AWSCloudSpec
-	openstack *clusterOpenstackPreservedValues
+	credentialsVersion types.Int64
+	aws                *models.AWSCloudSpec
+	openstack          *clusterOpenstackPreservedValues
 }
 
 type clusterOpenstackPreservedValues struct {
@@ -28,6 +30,14 @@ type clusterOpenstackPreservedValues struct {
 	openstackServerGroupID                types.String
 }
 
+// clusterCredentials holds the write-only cloud credentials, which are only
+// available in the configuration and never in the plan or state.
+type clusterCredentials struct {
+	openstackPassword                     string
+	openstackApplicationCredentialsSecret string
+	awsSecretAccessKey                    string
+}
+
 // flatteners
 
 func metakubeResourceClusterFlattenSpec(ctx context.Context, model *ClusterModel, in *models.ClusterSpec) diag.Diagnostics {
@@ -134,6 +144,8 @@ func getPreservedValuesFromModel(ctx context.Context, model *ClusterModel) clust
 		return values
 	}
 
+	values.credentialsVersion = clouds[0].CredentialsVersion
+
 	if !clouds[0].AWS.IsNull() && !clouds[0].AWS.IsUnknown() {
 		var awsSpecs []AWSCloudSpecModel
 		if diags := clouds[0].AWS.ElementsAs(ctx, &awsSpecs, false); !diags.HasError() && len(awsSpecs) > 0 {
@@ -180,6 +192,63 @@ func getPreservedValuesFromModel(ctx context.Context, model *ClusterModel) clust
 	return values
 }
 
+// getCredentialsFromModel returns the write-only credentials of a model read
+// from the configuration.
+func getCredentialsFromModel(ctx context.Context, model *ClusterModel) *clusterCredentials {
+	creds := &clusterCredentials{}
+
+	if model.Spec.IsNull() || model.Spec.IsUnknown() {
+		return creds
+	}
+
+	var specs []ClusterSpecModel
+	if diags := model.Spec.ElementsAs(ctx, &specs, false); diags.HasError() || len(specs) == 0 {
+		return creds
+	}
+
+	if specs[0].Cloud.IsNull() || specs[0].Cloud.IsUnknown() {
+		return creds
+	}
+
+	var clouds []ClusterCloudSpecModel
+	if diags := specs[0].Cloud.ElementsAs(ctx, &clouds, false); diags.HasError() || len(clouds) == 0 {
+		return creds
+	}
+
+	if !clouds[0].AWS.IsNull() && !clouds[0].AWS.IsUnknown() {
+		var awsSpecs []AWSCloudSpecModel
+		if diags := clouds[0].AWS.ElementsAs(ctx, &awsSpecs, false); !diags.HasError() && len(awsSpecs) > 0 {
+			creds.awsSecretAccessKey = awsSpecs[0].SecretAccessKeyWO.ValueString()
+		}
+	}
+
+	if !clouds[0].Openstack.IsNull() && !clouds[0].Openstack.IsUnknown() {
+		var osSpecs []OpenstackCloudSpecModel
+		if diags := clouds[0].Openstack.ElementsAs(ctx, &osSpecs, false); !diags.HasError() && len(osSpecs) > 0 {
+			if !osSpecs[0].UserCredentials.IsNull() && !osSpecs[0].UserCredentials.IsUnknown() {
+				var userCreds []OpenstackUserCredentialsModel
+				if diags := osSpecs[0].UserCredentials.ElementsAs(ctx, &userCreds, false); !diags.HasError() && len(userCreds) > 0 {
+					creds.openstackPassword = userCreds[0].PasswordWO.ValueString()
+				}
+			}
+
+			if !osSpecs[0].ApplicationCredentials.IsNull() && !osSpecs[0].ApplicationCredentials.IsUnknown() {
+				var appCreds []OpenstackApplicationCredentialsModel
+				if diags := osSpecs[0].ApplicationCredentials.ElementsAs(ctx, &appCreds, false); !diags.HasError() && len(appCreds) > 0 {
+					creds.openstackApplicationCredentialsSecret = appCreds[0].SecretWO.ValueString()
+				}
+			}
+		}
+	}
+
+	return creds
+}
+
+// getCredentialsVersion returns the credentials_version of the cloud block.
+func getCredentialsVersion(ctx context.Context, model *ClusterModel) types.Int64 {
+	return getPreservedValuesFromModel(ctx, model).credentialsVersion
+}
+
 func flattenUpdateWindow(ctx context.Context, specModel *ClusterSpecModel, in *models.UpdateWindow) diag.Diagnostics {
 	var diags diag.Diagnostics
 
@@ -206,6 +275,56 @@ func flattenUpdateWindow(ctx context.Context, specModel *ClusterSpecModel, in *m
 	return diags
 }
 
+// flattenClusterStatus sets the computed status attribute from the status and
+// health reported by the API.
+func flattenClusterStatus(ctx context.Context, model *ClusterModel, status *models.ClusterStatus, health *models.ClusterHealth) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	statusModel := ClusterStatusModel{
+		Version: types.StringNull(),
+		URL:     types.StringNull(),
+		Healthy: types.BoolNull(),
+		Health:  types.ObjectNull(clusterHealthAttrTypes()),
+	}
+	if status != nil {
+		statusModel.Version = types.StringValue(string(status.Version))
+		statusModel.URL = types.StringValue(status.URL)
+	}
+	if health != nil {
+		statusModel.Healthy = types.BoolValue(common.ClusterHealthy(health))
+		healthModel := ClusterHealthModel{
+			Apiserver:                    types.StringValue(flattenHealthStatus(health.Apiserver)),
+			Etcd:                         types.StringValue(flattenHealthStatus(health.Etcd)),
+			Controller:                   types.StringValue(flattenHealthStatus(health.Controller)),
+			Scheduler:                    types.StringValue(flattenHealthStatus(health.Scheduler)),
+			MachineController:            types.StringValue(flattenHealthStatus(health.MachineController)),
+			CloudProviderInfrastructure:  types.StringValue(flattenHealthStatus(health.CloudProviderInfrastructure)),
+			UserClusterControllerManager: types.StringValue(flattenHealthStatus(health.UserClusterControllerManager)),
+		}
+		healthValue, d := types.ObjectValueFrom(ctx, clusterHealthAttrTypes(), healthModel)
+		diags.Append(d...)
+		statusModel.Health = healthValue
+	}
+
+	statusValue, d := types.ObjectValueFrom(ctx, clusterStatusAttrTypes(), statusModel)
+	diags.Append(d...)
+	model.Status = statusValue
+
+	return diags
+}
+
+func flattenHealthStatus(in models.HealthStatus) string {
+	switch in {
+	case common.HealthStatusUp:
+		return "up"
+	case common.HealthStatusDown:
+		return "down"
+	case common.HealthStatusProvisioning:
+		return "provisioning"
+	}
+	return "unknown"
+}
+
 func flattenCniPlugin(ctx context.Context, specModel *ClusterSpecModel, in *models.CNIPluginSettings) diag.Diagnostics {
 	var diags diag.Diagnostics
 
@@ -234,8 +353,9 @@ func flattenClusterCloudSpec(ctx context.Context, specModel *ClusterSpecModel, v
 	}
 
 	cloudModel := ClusterCloudSpecModel{
-		AWS:       types.ListNull(types.ObjectType{AttrTypes: awsCloudSpecAttrTypes()}),
-		Openstack: types.ListNull(types.ObjectType{AttrTypes: openstackCloudSpecAttrTypes()}),
+		CredentialsVersion: values.credentialsVersion,
+		AWS:                types.ListNull(types.ObjectType{AttrTypes: awsCloudSpecAttrTypes()}),
+		Openstack:          types.ListNull(types.ObjectType{AttrTypes: openstackCloudSpecAttrTypes()}),
 	}
 
 	if in.Aws != nil {
@@ -306,7 +426,9 @@ func flattenAWSCloudSpec(ctx context.Context, cloudModel *ClusterCloudSpecModel,
 		return diags
 	}
 
-	awsModel := AWSCloudSpecModel{}
+	awsModel := AWSCloudSpecModel{
+		SecretAccessKeyWO: types.StringNull(),
+	}
 
 	if in.AccessKeyID != "" {
 		awsModel.AccessKeyID = types.StringValue(in.AccessKeyID)
@@ -433,6 +555,7 @@ func flattenOpenstackSpec(ctx context.Context, cloudModel *ClusterCloudSpecModel
 				ProjectName: values.openstackProjectName,
 				Username:    values.openstackUsername,
 				Password:    values.openstackPassword,
+				PasswordWO:  types.StringNull(),
 			}
 
 			objVal, d := types.ObjectValueFrom(ctx, openstackUserCredentialsAttrTypes(), userCredsModel)
@@ -449,8 +572,9 @@ func flattenOpenstackSpec(ctx context.Context, cloudModel *ClusterCloudSpecModel
 
 		if hasAppCreds {
 			appCredsModel := OpenstackApplicationCredentialsModel{
-				ID:     values.openstackApplicationCredentialsID,
-				Secret: values.openstackApplicationCredentialsSecret,
+				ID:       values.openstackApplicationCredentialsID,
+				Secret:   values.openstackApplicationCredentialsSecret,
+				SecretWO: types.StringNull(),
 			}
 
 			objVal, d := types.ObjectValueFrom(ctx, openstackApplicationCredentialsAttrTypes(), appCredsModel)
@@ -478,7 +602,10 @@ func flattenOpenstackSpec(ctx context.Context, cloudModel *ClusterCloudSpecModel
 
 // expanders
 
-func metakubeResourceClusterExpandSpec(ctx context.Context, model *ClusterModel, dcName string, include func(string) bool) *models.ClusterSpec {
+// metakubeResourceClusterExpandSpec builds the cluster spec from the model. The
+// write-only credentials in creds are added when creds is not nil, which is
+// only the case on create and when credentials_version changes.
+func metakubeResourceClusterExpandSpec(ctx context.Context, model *ClusterModel, dcName string, creds *clusterCredentials, include func(string) bool) *models.ClusterSpec {
 	if model.Spec.IsNull() || model.Spec.IsUnknown() {
 		return nil
 	}
@@ -555,7 +682,7 @@ func metakubeResourceClusterExpandSpec(ctx context.Context, model *ClusterModel,
 	}
 
 	if !spec.Cloud.IsNull() && !spec.Cloud.IsUnknown() && include("cloud") {
-		obj.Cloud = expandClusterCloudSpec(ctx, spec.Cloud, dcName, func(k string) bool { return include("cloud.0." + k) })
+		obj.Cloud = expandClusterCloudSpec(ctx, spec.Cloud, dcName, creds, func(k string) bool { return include("cloud.0." + k) })
 	}
 
 	// FIXME once we have proper server side validation for spec.BillingTenant we can remove this
@@ -621,7 +748,7 @@ func expandCniPlugin(ctx context.Context, obj types.Object) *models.CNIPluginSet
 	}
 }
 
-func expandClusterCloudSpec(ctx context.Context, list types.List, dcName string, include func(string) bool) *models.CloudSpec {
+func expandClusterCloudSpec(ctx context.Context, list types.List, dcName string, creds *clusterCredentials, include func(string) bool) *models.CloudSpec {
 	if list.IsNull() || list.IsUnknown() {
 		return nil
 	}
@@ -636,11 +763,11 @@ func expandClusterCloudSpec(ctx context.Context, list types.List, dcName string,
 	}
 
 	if !clouds[0].AWS.IsNull() && !clouds[0].AWS.IsUnknown() && include("aws") {
-		obj.Aws = expandAWSCloudSpec(ctx, clouds[0].AWS, func(k string) bool { return include("aws.0." + k) })
+		obj.Aws = expandAWSCloudSpec(ctx, clouds[0].AWS, creds, func(k string) bool { return include("aws.0." + k) })
 	}
 
 	if !clouds[0].Openstack.IsNull() && !clouds[0].Openstack.IsUnknown() && include("openstack") {
-		obj.Openstack = expandOpenstackCloudSpec(ctx, clouds[0].Openstack, func(k string) bool { return include("openstack.0." + k) })
+		obj.Openstack = expandOpenstackCloudSpec(ctx, clouds[0].Openstack, creds, func(k string) bool { return include("openstack.0." + k) })
 	}
 
 	return obj
@@ -672,7 +799,7 @@ func expandClusterSys11Auth(ctx context.Context, list types.List) *models.Sys11A
 	return obj
 }
 
-func expandAWSCloudSpec(ctx context.Context, list types.List, include func(string) bool) *models.AWSCloudSpec {
+func expandAWSCloudSpec(ctx context.Context, list types.List, creds *clusterCredentials, include func(string) bool) *models.AWSCloudSpec {
 	if list.IsNull() || list.IsUnknown() {
 		return nil
 	}
@@ -699,6 +826,10 @@ func expandAWSCloudSpec(ctx context.Context, list types.List, include func(strin
 		}
 	}
 
+	if creds != nil && creds.awsSecretAccessKey != "" && include("secret_access_key_wo") {
+		obj.SecretAccessKey = creds.awsSecretAccessKey
+	}
+
 	if !aws.VPCID.IsNull() && !aws.VPCID.IsUnknown() && include("vpc_id") {
 		v := aws.VPCID.ValueString()
 		if v != "" {
@@ -744,7 +875,7 @@ func expandAWSCloudSpec(ctx context.Context, list types.List, include func(strin
 	return obj
 }
 
-func expandOpenstackCloudSpec(ctx context.Context, list types.List, include func(string) bool) *models.OpenstackCloudSpec {
+func expandOpenstackCloudSpec(ctx context.Context, list types.List, creds *clusterCredentials, include func(string) bool) *models.OpenstackCloudSpec {
 	if list.IsNull() || list.IsUnknown() {
 		return nil
 	}
@@ -815,6 +946,10 @@ func expandOpenstackCloudSpec(ctx context.Context, list types.List, include func
 					obj.ApplicationCredentialSecret = v
 				}
 			}
+
+			if creds != nil && creds.openstackApplicationCredentialsSecret != "" && include("application_credentials.0.secret_wo") {
+				obj.ApplicationCredentialSecret = creds.openstackApplicationCredentialsSecret
+			}
 		}
 	}
 
@@ -833,6 +968,9 @@ func expandOpenstackCloudSpec(ctx context.Context, list types.List, include func
 					obj.Password = v
 				}
 			}
+			if creds != nil && creds.openstackPassword != "" {
+				obj.Password = creds.openstackPassword
+			}
 			if !userCreds[0].ProjectID.IsNull() && !userCreds[0].ProjectID.IsUnknown() {
 				v := userCreds[0].ProjectID.ValueString()
 				if v != "" {
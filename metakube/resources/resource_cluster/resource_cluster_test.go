@@ -98,6 +98,10 @@ func TestAccMetakubeCluster_Openstack_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "spec.0.cloud.0.openstack.0.subnet_cidr", "192.168.2.0/24"),
 					resource.TestCheckResourceAttrSet(resourceName, "kube_config"),
 					resource.TestCheckResourceAttr(resourceName, "spec.0.audit_logging", "false"),
+					resource.TestCheckResourceAttr(resourceName, "status.healthy", "true"),
+					resource.TestCheckResourceAttr(resourceName, "status.health.apiserver", "up"),
+					resource.TestCheckResourceAttr(resourceName, "status.version", data.Version),
+					resource.TestCheckResourceAttrSet(resourceName, "status.url"),
 					resource.TestCheckResourceAttrSet(resourceName, "creation_timestamp"),
 					resource.TestCheckResourceAttrSet(resourceName, "deletion_timestamp"),
 				),
@@ -159,6 +163,11 @@ func TestAccMetakubeCluster_Openstack_Basic(t *testing.T) {
 					return data.ProjectID + ":" + s.RootModule().Resources[resourceName].Primary.ID, nil
 				},
 			},
+			{
+				ResourceName:    resourceName,
+				ImportState:     true,
+				ImportStateKind: resource.ImportBlockWithResourceIdentity,
+			},
 			{
 				Config:   config2.String(),
 				PlanOnly: true,
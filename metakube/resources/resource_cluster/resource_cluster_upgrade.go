@@ -0,0 +1,245 @@
+package resource_cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/syseleven/go-metakube/client/project"
+	"github.com/syseleven/go-metakube/client/versions"
+	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+// getUpgradePolicyFromModel returns the upgrade_policy block, nil when it is not set.
+func getUpgradePolicyFromModel(ctx context.Context, model *ClusterModel) *UpgradePolicyModel {
+	if model.UpgradePolicy.IsNull() || model.UpgradePolicy.IsUnknown() {
+		return nil
+	}
+	var policies []UpgradePolicyModel
+	if diags := model.UpgradePolicy.ElementsAs(ctx, &policies, false); diags.HasError() || len(policies) == 0 {
+		return nil
+	}
+	return &policies[0]
+}
+
+// upgradeNodeDeployments upgrades the kubelet of all node deployments of the
+// cluster after its control plane was upgraded to clusterVersion. Node
+// deployments are patched max_parallel at a time, each batch has to roll out
+// before the next one starts.
+func (r *clusterResource) upgradeNodeDeployments(ctx context.Context, projectID, clusterID, clusterVersion string, policy *UpgradePolicyModel) error {
+	timeout, err := time.ParseDuration(policy.NodeDeploymentTimeout.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid node_deployment_timeout: %v", err)
+	}
+	maxParallel := int(policy.MaxParallel.ValueInt64())
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+
+	p := versions.NewGetNodeUpgradesParams().WithContext(ctx)
+	p.SetControlPlaneVersion(&clusterVersion)
+	upgrades, err := r.meta.Client.Versions.GetNodeUpgrades(p, r.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("get node upgrades: %s", common.StringifyResponseError(err))
+	}
+	kubeletVersion, err := selectKubeletVersion(clusterVersion, upgrades.Payload)
+	if err != nil {
+		return err
+	}
+
+	p1 := project.NewListMachineDeploymentsParams().
+		WithContext(ctx).
+		WithProjectID(projectID).
+		WithClusterID(clusterID)
+	res, err := r.meta.Client.Project.ListMachineDeployments(p1, r.meta.Auth)
+	if err != nil {
+		return fmt.Errorf("list node deployments: %s", common.StringifyResponseError(err))
+	}
+
+	pending := nodeDeploymentsToUpgrade(res.Payload, kubeletVersion)
+	var upgraded []*models.NodeDeployment
+	for len(pending) > 0 {
+		batch := pending
+		if len(batch) > maxParallel {
+			batch = batch[:maxParallel]
+		}
+
+		for _, nd := range batch {
+			r.meta.Log.Infof(ctx, "upgrading kubelet of node deployment '%s' to %s", nd.ID, kubeletVersion)
+			if err := r.patchNodeDeploymentKubelet(ctx, timeout, projectID, clusterID, nd.ID, kubeletVersion); err != nil {
+				return upgradeProgressError(err, upgraded, pending)
+			}
+		}
+		for _, nd := range batch {
+			if err := r.waitForKubeletRollout(ctx, timeout, projectID, clusterID, nd.ID, kubeletVersion); err != nil {
+				return upgradeProgressError(fmt.Errorf("node deployment '%s' not upgraded: %v", nd.ID, err), upgraded, pending)
+			}
+		}
+		upgraded = append(upgraded, batch...)
+		pending = pending[len(batch):]
+	}
+
+	return nil
+}
+
+// upgradeProgressError adds the node deployments that were upgraded and the
+// ones still pending to err.
+func upgradeProgressError(err error, upgraded, pending []*models.NodeDeployment) error {
+	return fmt.Errorf("%v. Upgraded node deployments: %s. Pending node deployments: %s", err, nodeDeploymentNames(upgraded), nodeDeploymentNames(pending))
+}
+
+// nodeDeploymentNames lists the node deployments as name (id), none when empty.
+func nodeDeploymentNames(nodeDeployments []*models.NodeDeployment) string {
+	if len(nodeDeployments) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(nodeDeployments))
+	for _, nd := range nodeDeployments {
+		names = append(names, fmt.Sprintf("'%s' (%s)", nd.Name, nd.ID))
+	}
+	return strings.Join(names, ", ")
+}
+
+func (r *clusterResource) patchNodeDeploymentKubelet(ctx context.Context, timeout time.Duration, projectID, clusterID, nodeDeploymentID, kubeletVersion string) error {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"versions": map[string]interface{}{
+					"kubelet": kubeletVersion,
+				},
+			},
+		},
+	}
+
+	return common.RetryContext(ctx, timeout, func() *common.RetryError {
+		p := project.NewPatchMachineDeploymentParams().
+			WithContext(ctx).
+			WithProjectID(projectID).
+			WithClusterID(clusterID).
+			WithMachineDeploymentID(nodeDeploymentID).
+			WithPatch(patch)
+		if _, err := r.meta.Client.Project.PatchMachineDeployment(p, r.meta.Auth); err != nil {
+			errStr := common.StringifyResponseError(err)
+			if strings.Contains(errStr, "the object has been modified") {
+				return common.RetryableError(fmt.Errorf("patch node deployment '%s': %s", nodeDeploymentID, errStr))
+			}
+			return common.NonRetryableError(fmt.Errorf("patch node deployment '%s': %s", nodeDeploymentID, errStr))
+		}
+		return nil
+	})
+}
+
+// waitForKubeletRollout waits until all nodes of the node deployment run
+// kubeletVersion. Right after the patch the old nodes are still ready, so
+// waiting for readiness alone would return before the rollout started.
+func (r *clusterResource) waitForKubeletRollout(ctx context.Context, timeout time.Duration, projectID, clusterID, nodeDeploymentID, kubeletVersion string) error {
+	return common.RetryContext(ctx, timeout, func() *common.RetryError {
+		p := project.NewGetMachineDeploymentParams().
+			WithContext(ctx).
+			WithProjectID(projectID).
+			WithClusterID(clusterID).
+			WithMachineDeploymentID(nodeDeploymentID)
+		nd, err := r.meta.Client.Project.GetMachineDeployment(p, r.meta.Auth)
+		if err != nil {
+			return common.RetryableError(fmt.Errorf("get node deployment: %s", common.StringifyResponseError(err)))
+		}
+
+		p2 := project.NewListMachineDeploymentNodesParams().
+			WithContext(ctx).
+			WithProjectID(projectID).
+			WithClusterID(clusterID).
+			WithMachineDeploymentID(nodeDeploymentID)
+		nodes, err := r.meta.Client.Project.ListMachineDeploymentNodes(p2, r.meta.Auth)
+		if err != nil {
+			return common.RetryableError(fmt.Errorf("list nodes: %s", common.StringifyResponseError(err)))
+		}
+
+		if err := checkKubeletRollout(nd.Payload, nodes.Payload, kubeletVersion); err != nil {
+			r.meta.Log.Debugf(ctx, "waiting for node deployment '%s': %v", nodeDeploymentID, err)
+			return common.RetryableError(err)
+		}
+		return nil
+	})
+}
+
+// checkKubeletRollout returns an error until the node deployment has no
+// unavailable replicas and each of its nodes reports kubeletVersion.
+func checkKubeletRollout(nd *models.NodeDeployment, nodes []*models.Node, kubeletVersion string) error {
+	want, err := version.NewVersion(kubeletVersion)
+	if err != nil {
+		return fmt.Errorf("unable to parse kubelet version %s: %v", kubeletVersion, err)
+	}
+	if nd == nil || nd.Spec == nil || nd.Spec.Replicas == nil || nd.Status == nil {
+		return fmt.Errorf("node deployment status not available")
+	}
+	if nd.Status.UnavailableReplicas != 0 {
+		return fmt.Errorf("%d unavailable replicas", nd.Status.UnavailableReplicas)
+	}
+	if len(nodes) != int(*nd.Spec.Replicas) {
+		return fmt.Errorf("node count mismatch, want %d got %d", *nd.Spec.Replicas, len(nodes))
+	}
+	for _, node := range nodes {
+		if node == nil || node.Status == nil || node.Status.NodeInfo == nil {
+			return fmt.Errorf("node info not available")
+		}
+		got, err := version.NewVersion(node.Status.NodeInfo.KubeletVersion)
+		if err != nil || !got.Equal(want) {
+			return fmt.Errorf("node '%s' runs kubelet %q, want %s", node.Name, node.Status.NodeInfo.KubeletVersion, kubeletVersion)
+		}
+	}
+	return nil
+}
+
+// selectKubeletVersion returns the newest kubelet version available for the
+// control plane version that is not newer than the control plane itself.
+func selectKubeletVersion(clusterVersion string, available []*models.MasterVersion) (string, error) {
+	cluster, err := version.NewVersion(clusterVersion)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse cluster version %s: %v", clusterVersion, err)
+	}
+
+	var selected *version.Version
+	var candidates []string
+	for _, item := range available {
+		if item == nil || item.RestrictedByKubeletVersion {
+			continue
+		}
+		candidates = append(candidates, item.Version)
+		v, err := version.NewVersion(item.Version)
+		if err != nil || v.GreaterThan(cluster) {
+			continue
+		}
+		if selected == nil || v.GreaterThan(selected) {
+			selected = v
+		}
+	}
+	if selected == nil {
+		return "", fmt.Errorf("no kubelet version available for control plane version %s, available versions %v", clusterVersion, candidates)
+	}
+	return selected.Original(), nil
+}
+
+// nodeDeploymentsToUpgrade returns the node deployments with a kubelet older
+// than kubeletVersion.
+func nodeDeploymentsToUpgrade(nodeDeployments []*models.NodeDeployment, kubeletVersion string) []*models.NodeDeployment {
+	target, err := version.NewVersion(kubeletVersion)
+	if err != nil {
+		return nil
+	}
+
+	var ret []*models.NodeDeployment
+	for _, nd := range nodeDeployments {
+		if nd == nil || nd.Spec == nil || nd.Spec.Template == nil || nd.Spec.Template.Versions == nil {
+			continue
+		}
+		current, err := version.NewVersion(nd.Spec.Template.Versions.Kubelet)
+		if err != nil || !current.LessThan(target) {
+			continue
+		}
+		ret = append(ret, nd)
+	}
+	return ret
+}
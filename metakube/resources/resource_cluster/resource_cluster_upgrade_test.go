@@ -0,0 +1,190 @@
+package resource_cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/syseleven/go-metakube/models"
+)
+
+// nodeDeployment returns a node deployment with the given kubelet version,
+// name is used as its ID as well.
+func nodeDeployment(name, kubelet string) *models.NodeDeployment {
+	return &models.NodeDeployment{
+		ID:   name,
+		Name: name,
+		Spec: &models.NodeDeploymentSpec{
+			Template: &models.NodeSpec{
+				Versions: &models.NodeVersionInfo{Kubelet: kubelet},
+			},
+		},
+	}
+}
+
+func TestSelectKubeletVersion(t *testing.T) {
+	available := []*models.MasterVersion{
+		{Version: "1.30.9"},
+		{Version: "1.31.5"},
+		{Version: "1.31.6", RestrictedByKubeletVersion: true},
+		{Version: "1.32.1"},
+	}
+
+	cases := []struct {
+		name           string
+		clusterVersion string
+		expected       string
+		expectError    bool
+	}{
+		{
+			name:           "newest not newer than control plane",
+			clusterVersion: "1.31.7",
+			expected:       "1.31.5",
+		},
+		{
+			name:           "same as control plane",
+			clusterVersion: "1.32.1",
+			expected:       "1.32.1",
+		},
+		{
+			name:           "none available",
+			clusterVersion: "1.29.0",
+			expectError:    true,
+		},
+		{
+			name:           "invalid cluster version",
+			clusterVersion: "latest",
+			expectError:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := selectKubeletVersion(tc.clusterVersion, available)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNodeDeploymentsToUpgrade(t *testing.T) {
+	got := nodeDeploymentsToUpgrade([]*models.NodeDeployment{
+		nodeDeployment("old", "1.30.9"),
+		nodeDeployment("current", "1.31.5"),
+		nodeDeployment("unparsable", ""),
+		{ID: "no-spec"},
+		nil,
+		nodeDeployment("older", "1.29.3"),
+	}, "1.31.5")
+
+	if len(got) != 2 || got[0].ID != "old" || got[1].ID != "older" {
+		var ids []string
+		for _, nd := range got {
+			ids = append(ids, nd.ID)
+		}
+		t.Errorf("expected [old older], got %v", ids)
+	}
+}
+
+func TestCheckKubeletRollout(t *testing.T) {
+	replicas := int32(2)
+	nd := func(unavailable int32) *models.NodeDeployment {
+		return &models.NodeDeployment{
+			Spec:   &models.NodeDeploymentSpec{Replicas: &replicas},
+			Status: &models.MachineDeploymentStatus{UnavailableReplicas: unavailable},
+		}
+	}
+	node := func(name, kubelet string) *models.Node {
+		return &models.Node{
+			Name:   name,
+			Status: &models.NodeStatus{NodeInfo: &models.NodeSystemInfo{KubeletVersion: kubelet}},
+		}
+	}
+
+	cases := []struct {
+		name        string
+		nd          *models.NodeDeployment
+		nodes       []*models.Node
+		expectError bool
+	}{
+		{
+			name:  "rolled out",
+			nd:    nd(0),
+			nodes: []*models.Node{node("a", "v1.31.5"), node("b", "v1.31.5")},
+		},
+		{
+			name:        "rollout not started",
+			nd:          nd(0),
+			nodes:       []*models.Node{node("a", "v1.30.9"), node("b", "v1.30.9")},
+			expectError: true,
+		},
+		{
+			name:        "rollout in progress",
+			nd:          nd(0),
+			nodes:       []*models.Node{node("a", "v1.31.5"), node("b", "v1.30.9")},
+			expectError: true,
+		},
+		{
+			name:        "unavailable replicas",
+			nd:          nd(1),
+			nodes:       []*models.Node{node("a", "v1.31.5"), node("b", "v1.31.5")},
+			expectError: true,
+		},
+		{
+			name:        "surge node not removed yet",
+			nd:          nd(0),
+			nodes:       []*models.Node{node("a", "v1.31.5"), node("b", "v1.31.5"), node("c", "v1.30.9")},
+			expectError: true,
+		},
+		{
+			name:        "node info missing",
+			nd:          nd(0),
+			nodes:       []*models.Node{node("a", "v1.31.5"), {Name: "b"}},
+			expectError: true,
+		},
+		{
+			name:        "status missing",
+			nd:          &models.NodeDeployment{Spec: &models.NodeDeploymentSpec{Replicas: &replicas}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkKubeletRollout(tc.nd, tc.nodes, "1.31.5")
+			if tc.expectError && err == nil {
+				t.Fatal("expected an error")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUpgradeProgressError(t *testing.T) {
+	err := upgradeProgressError(
+		errors.New("node deployment 'b' not upgraded: timeout"),
+		[]*models.NodeDeployment{nodeDeployment("a", "1.31.5")},
+		[]*models.NodeDeployment{nodeDeployment("b", "1.30.9"), nodeDeployment("c", "1.30.9")},
+	)
+	want := "node deployment 'b' not upgraded: timeout. Upgraded node deployments: 'a' (a). Pending node deployments: 'b' (b), 'c' (c)"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+
+	err = upgradeProgressError(errors.New("patch failed"), nil, []*models.NodeDeployment{nodeDeployment("a", "1.30.9")})
+	want = "patch failed. Upgraded node deployments: none. Pending node deployments: 'a' (a)"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -52,7 +53,9 @@ func (data *metakubeResourceClusterOpenstackValidationData) setParams(ctx contex
 	p.SetContext(ctx)
 }
 
-func newOpenstackValidationData(ctx context.Context, model *ClusterModel) metakubeResourceClusterOpenstackValidationData {
+// newOpenstackValidationData collects the OpenStack settings of the model. The
+// write-only credentials in creds are used where the model has none.
+func newOpenstackValidationData(ctx context.Context, model *ClusterModel, creds *clusterCredentials) metakubeResourceClusterOpenstackValidationData {
 	data := metakubeResourceClusterOpenstackValidationData{
 		domain: common.StrToPtr("Default"),
 	}
@@ -111,6 +114,15 @@ func newOpenstackValidationData(ctx context.Context, model *ClusterModel) metaku
 		}
 	}
 
+	if creds != nil {
+		if data.password == nil && creds.openstackPassword != "" {
+			data.password = &creds.openstackPassword
+		}
+		if data.applicationCredentialsSecret == nil && creds.openstackApplicationCredentialsSecret != "" {
+			data.applicationCredentialsSecret = &creds.openstackApplicationCredentialsSecret
+		}
+	}
+
 	return data
 }
 
@@ -125,7 +137,7 @@ func stringValueToPtr(s types.String) *string {
 	return &val
 }
 
-func metakubeResourceClusterValidateClusterFields(ctx context.Context, model *ClusterModel, k *common.MetaKubeProviderMeta, isUpdate bool) diag.Diagnostics {
+func metakubeResourceClusterValidateClusterFields(ctx context.Context, model *ClusterModel, creds *clusterCredentials, k *common.MetaKubeProviderMeta, isUpdate bool) diag.Diagnostics {
 	var ret diag.Diagnostics
 
 	ret.Append(metakubeResourceValidateVersionExistence(ctx, model, k, isUpdate)...)
@@ -134,14 +146,14 @@ func metakubeResourceClusterValidateClusterFields(ctx context.Context, model *Cl
 		return ret
 	}
 
-	data := newOpenstackValidationData(ctx, model)
+	data := newOpenstackValidationData(ctx, model, creds)
 	hasAuthData := (data.username == nil || *data.username != "") && (data.applicationCredentialsID == nil || *data.applicationCredentialsSecret != "")
 	if hasAuthData {
-		ret.Append(metakubeResourceClusterValidateFloatingIPPool(ctx, model, k)...)
-		ret.Append(metakubeResourceClusterValidateOpenstackNetwork(ctx, model, k)...)
-		ret.Append(diagnoseOpenstackSubnetWithIDExistsIfSet(ctx, model, k)...)
+		ret.Append(metakubeResourceClusterValidateFloatingIPPool(ctx, model, creds, k)...)
+		ret.Append(metakubeResourceClusterValidateOpenstackNetwork(ctx, model, creds, k)...)
+		ret.Append(diagnoseOpenstackSubnetWithIDExistsIfSet(ctx, model, creds, k)...)
 	}
-	ret.Append(metakubeResourceClusterValidateAccessCredentialsSet(ctx, model)...)
+	ret.Append(metakubeResourceClusterValidateAccessCredentialsSet(ctx, model, creds)...)
 	return ret
 }
 
@@ -170,30 +182,137 @@ func hasOpenstackConfig(ctx context.Context, model *ClusterModel) bool {
 	return true
 }
 
+// metakubeResourceClusterValidateVersionUpgrade checks that the cluster can be
+// upgraded to newVersion: one minor version at a time, to a version offered by
+// the cluster-upgrades API, and without exceeding the supported kubelet skew
+// of its node deployments.
 func metakubeResourceClusterValidateVersionUpgrade(ctx context.Context, projectID, newVersion string, cluster *models.Cluster, k *common.MetaKubeProviderMeta) diag.Diagnostics {
+	var ret diag.Diagnostics
+	versionPath := path.Root("spec").AtListIndex(0).AtName("version")
+	currentVersion := string(cluster.Spec.Version)
+
 	p := project.NewGetClusterUpgradesV2Params().
 		WithContext(ctx).
 		WithProjectID(projectID).
 		WithClusterID(cluster.ID)
 	r, err := k.Client.Project.GetClusterUpgradesV2(p, k.Auth)
 	if err != nil {
-		var ret diag.Diagnostics
-		ret.AddError("Failed to get cluster upgrades", err.Error())
+		ret.AddError("Failed to get cluster upgrades", common.StringifyResponseError(err))
 		return ret
 	}
 	var available []string
+	var upgrade *models.MasterVersion
 	for _, item := range r.Payload {
+		if item == nil {
+			continue
+		}
 		available = append(available, item.Version)
 		if item.Version == newVersion {
-			return nil
+			upgrade = item
+		}
+	}
+
+	if err := checkVersionUpgradeStep(currentVersion, newVersion); err != nil {
+		ret.AddAttributeError(
+			versionPath,
+			fmt.Sprintf("Not allowed upgrade %s->%s", currentVersion, newVersion),
+			fmt.Sprintf("%s. Please select one of available upgrades: %v", err, available),
+		)
+		return ret
+	}
+	if upgrade == nil {
+		ret.AddAttributeError(
+			versionPath,
+			fmt.Sprintf("Not allowed upgrade %s->%s", currentVersion, newVersion),
+			fmt.Sprintf("Please select one of available upgrades: %v", available),
+		)
+		return ret
+	}
+
+	p1 := project.NewListMachineDeploymentsParams().
+		WithContext(ctx).
+		WithProjectID(projectID).
+		WithClusterID(cluster.ID)
+	nodeDeployments, err := k.Client.Project.ListMachineDeployments(p1, k.Auth)
+	if err != nil {
+		ret.AddError("Failed to list node deployments", common.StringifyResponseError(err))
+		return ret
+	}
+	skewErrors := checkKubeletSkew(newVersion, nodeDeployments.Payload)
+	for _, err := range skewErrors {
+		ret.AddAttributeError(
+			versionPath,
+			fmt.Sprintf("Not allowed upgrade %s->%s", currentVersion, newVersion),
+			fmt.Sprintf("%s. Upgrade the node deployment first.", err),
+		)
+	}
+	if len(skewErrors) == 0 && upgrade.RestrictedByKubeletVersion {
+		ret.AddAttributeError(
+			versionPath,
+			fmt.Sprintf("Not allowed upgrade %s->%s", currentVersion, newVersion),
+			"The version is not compatible with the kubelet of one of the node deployments. Upgrade the node deployments first.",
+		)
+	}
+	return ret
+}
+
+// checkVersionUpgradeStep enforces that control planes are upgraded one minor
+// version at a time and never downgraded.
+func checkVersionUpgradeStep(currentVersion, newVersion string) error {
+	current, err := version.NewVersion(currentVersion)
+	if err != nil {
+		return fmt.Errorf("unable to parse current version %s", currentVersion)
+	}
+	target, err := version.NewVersion(newVersion)
+	if err != nil {
+		return fmt.Errorf("unable to parse version %s", newVersion)
+	}
+
+	if target.LessThan(current) {
+		return fmt.Errorf("downgrading the cluster is not supported")
+	}
+	cs, ts := current.Segments(), target.Segments()
+	if ts[0] != cs[0] {
+		return fmt.Errorf("upgrading the cluster from major version %d to %d is not supported", cs[0], ts[0])
+	}
+	if ts[1] > cs[1]+1 {
+		return fmt.Errorf("the cluster can only be upgraded one minor version at a time, upgrade to %d.%d first", cs[0], cs[1]+1)
+	}
+	return nil
+}
+
+// maxKubeletSkew returns how many minor versions a kubelet may be older than
+// the control plane, see https://kubernetes.io/releases/version-skew-policy/.
+func maxKubeletSkew(controlPlane *version.Version) int {
+	if s := controlPlane.Segments(); s[0] == 1 && s[1] < 28 {
+		return 2
+	}
+	return 3
+}
+
+// checkKubeletSkew returns an error for every node deployment whose kubelet
+// would be too old for a control plane of newVersion.
+func checkKubeletSkew(newVersion string, nodeDeployments []*models.NodeDeployment) []error {
+	target, err := version.NewVersion(newVersion)
+	if err != nil {
+		return nil
+	}
+	maxSkew := maxKubeletSkew(target)
+	ts := target.Segments()
+
+	var ret []error
+	for _, nd := range nodeDeployments {
+		if nd == nil || nd.Spec == nil || nd.Spec.Template == nil || nd.Spec.Template.Versions == nil {
+			continue
+		}
+		kubelet, err := version.NewVersion(nd.Spec.Template.Versions.Kubelet)
+		if err != nil {
+			continue
+		}
+		if ks := kubelet.Segments(); ks[0] != ts[0] || ts[1]-ks[1] > maxSkew {
+			ret = append(ret, fmt.Errorf("node deployment '%s' runs kubelet %s, which is more than %d minor versions older than %s", nd.Name, kubelet, maxSkew, newVersion))
 		}
 	}
-	var ret diag.Diagnostics
-	ret.AddAttributeError(
-		path.Root("spec").AtListIndex(0).AtName("version"),
-		fmt.Sprintf("Not allowed upgrade %s->%s", cluster.Spec.Version, newVersion),
-		fmt.Sprintf("Please select one of available upgrades: %v", available),
-	)
 	return ret
 }
 
@@ -241,8 +360,8 @@ func metakubeResourceValidateVersionExistence(ctx context.Context, model *Cluste
 	return ret
 }
 
-func metakubeResourceClusterValidateFloatingIPPool(ctx context.Context, model *ClusterModel, k *common.MetaKubeProviderMeta) diag.Diagnostics {
-	data := newOpenstackValidationData(ctx, model)
+func metakubeResourceClusterValidateFloatingIPPool(ctx context.Context, model *ClusterModel, creds *clusterCredentials, k *common.MetaKubeProviderMeta) diag.Diagnostics {
+	data := newOpenstackValidationData(ctx, model, creds)
 
 	floatingIPPool, ok := getOpenstackFieldString(ctx, model, func(os OpenstackCloudSpecModel) types.String {
 		return os.FloatingIPPool
@@ -274,8 +393,8 @@ func metakubeResourceClusterValidateFloatingIPPool(ctx context.Context, model *C
 	return nil
 }
 
-func metakubeResourceClusterValidateOpenstackNetwork(ctx context.Context, model *ClusterModel, k *common.MetaKubeProviderMeta) diag.Diagnostics {
-	data := newOpenstackValidationData(ctx, model)
+func metakubeResourceClusterValidateOpenstackNetwork(ctx context.Context, model *ClusterModel, creds *clusterCredentials, k *common.MetaKubeProviderMeta) diag.Diagnostics {
+	data := newOpenstackValidationData(ctx, model, creds)
 
 	network, ok := getOpenstackFieldString(ctx, model, func(os OpenstackCloudSpecModel) types.String {
 		return os.Network
@@ -307,8 +426,8 @@ func metakubeResourceClusterValidateOpenstackNetwork(ctx context.Context, model
 	return nil
 }
 
-func diagnoseOpenstackSubnetWithIDExistsIfSet(ctx context.Context, model *ClusterModel, k *common.MetaKubeProviderMeta) diag.Diagnostics {
-	data := newOpenstackValidationData(ctx, model)
+func diagnoseOpenstackSubnetWithIDExistsIfSet(ctx context.Context, model *ClusterModel, creds *clusterCredentials, k *common.MetaKubeProviderMeta) diag.Diagnostics {
+	data := newOpenstackValidationData(ctx, model, creds)
 	if data.network == nil || data.subnetID == nil {
 		return nil
 	}
@@ -410,8 +529,8 @@ func findSubnet(list []*models.OpenstackSubnet, id string) *models.OpenstackSubn
 	return nil
 }
 
-func metakubeResourceClusterValidateAccessCredentialsSet(ctx context.Context, model *ClusterModel) diag.Diagnostics {
-	data := newOpenstackValidationData(ctx, model)
+func metakubeResourceClusterValidateAccessCredentialsSet(ctx context.Context, model *ClusterModel, creds *clusterCredentials) diag.Diagnostics {
+	data := newOpenstackValidationData(ctx, model, creds)
 
 	username := data.username != nil && *data.username != ""
 	password := data.password != nil && *data.password != ""
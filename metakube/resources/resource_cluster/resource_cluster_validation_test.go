@@ -0,0 +1,70 @@
+package resource_cluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/syseleven/go-metakube/models"
+)
+
+func TestCheckVersionUpgradeStep(t *testing.T) {
+	cases := []struct {
+		current     string
+		target      string
+		expectError string
+	}{
+		{current: "1.30.4", target: "1.30.9"},
+		{current: "1.30.4", target: "1.31.0"},
+		{current: "1.30.4", target: "1.32.1", expectError: "upgrade to 1.31 first"},
+		{current: "1.30.4", target: "1.29.8", expectError: "downgrading"},
+		{current: "1.30.4", target: "2.0.0", expectError: "major version 1 to 2"},
+		{current: "1.30.4", target: "latest", expectError: "unable to parse"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.current+"->"+tc.target, func(t *testing.T) {
+			err := checkVersionUpgradeStep(tc.current, tc.target)
+			if tc.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+				t.Errorf("expected an error containing %q, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestCheckKubeletSkew(t *testing.T) {
+	nodeDeployments := []*models.NodeDeployment{
+		nodeDeployment("current", "1.31.5"),
+		nodeDeployment("three-behind", "1.29.8"),
+		nodeDeployment("four-behind", "1.28.3"),
+		{Name: "no-spec"},
+	}
+
+	cases := []struct {
+		name     string
+		target   string
+		expected int
+	}{
+		{name: "within skew", target: "1.31.6", expected: 0},
+		{name: "one node deployment too old", target: "1.32.1", expected: 1},
+		{name: "two node deployments too old", target: "1.33.0", expected: 2},
+		{name: "newer kubelets are not checked", target: "1.27.2", expected: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := checkKubeletSkew(tc.target, nodeDeployments); len(got) != tc.expected {
+				t.Errorf("expected %d errors, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	if got := checkKubeletSkew("1.27.2", []*models.NodeDeployment{nodeDeployment("old", "1.24.1")}); len(got) != 1 {
+		t.Errorf("expected a skew of 3 to be rejected for 1.27, got %v", got)
+	}
+}
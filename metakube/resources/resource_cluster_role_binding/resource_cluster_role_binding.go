@@ -18,6 +18,7 @@ var (
 	_ resource.Resource                = &metakubeClusterRoleBinding{}
 	_ resource.ResourceWithConfigure   = &metakubeClusterRoleBinding{}
 	_ resource.ResourceWithImportState = &metakubeClusterRoleBinding{}
+	_ resource.ResourceWithIdentity    = &metakubeClusterRoleBinding{}
 )
 
 func NewClusterRoleBinding() resource.Resource {
@@ -36,6 +37,10 @@ func (r *metakubeClusterRoleBinding) Schema(ctx context.Context, _ resource.Sche
 	resp.Schema = ClusterRoleBindingSchema(ctx)
 }
 
+func (r *metakubeClusterRoleBinding) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = common.ClusterResourceIdentitySchema("Name of the bound cluster role")
+}
+
 func (r *metakubeClusterRoleBinding) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -54,6 +59,13 @@ func (r *metakubeClusterRoleBinding) Configure(_ context.Context, req resource.C
 }
 
 func (r *metakubeClusterRoleBinding) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemRBAC)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("create", "cluster role binding"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan ClusterRoleBindingModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -108,6 +120,7 @@ func (r *metakubeClusterRoleBinding) Create(ctx context.Context, req resource.Cr
 
 			e, ok := err.(*project.BindUserToClusterRoleV2Default)
 			if ok && (e.Code() == http.StatusConflict || e.Code() == http.StatusNotFound) {
+				r.meta.Log.Debugf(ctx, "cluster '%s' not ready to bind cluster role '%s', retrying: %s", clusterID, clusterRoleName, common.StringifyResponseError(err))
 				lastErr = err
 				select {
 				case <-ctx.Done():
@@ -131,9 +144,12 @@ func (r *metakubeClusterRoleBinding) Create(ctx context.Context, req resource.Cr
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, clusterRoleBindingIdentity(&plan))...)
 }
 
 func (r *metakubeClusterRoleBinding) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemRBAC)
+
 	var data ClusterRoleBindingModel
 
 	diags := req.State.Get(ctx, &data)
@@ -166,6 +182,7 @@ func (r *metakubeClusterRoleBinding) Read(ctx context.Context, req resource.Read
 
 			diags = resp.State.Set(ctx, &data)
 			resp.Diagnostics.Append(diags...)
+			resp.Diagnostics.Append(resp.Identity.Set(ctx, clusterRoleBindingIdentity(&data))...)
 
 			return
 		}
@@ -177,6 +194,13 @@ func (r *metakubeClusterRoleBinding) Read(ctx context.Context, req resource.Read
 }
 
 func (r *metakubeClusterRoleBinding) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemRBAC)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("delete", "cluster role binding"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var state ClusterRoleBindingModel
 
 	diags := req.State.Get(ctx, &state)
@@ -198,6 +222,7 @@ func (r *metakubeClusterRoleBinding) Delete(ctx context.Context, req resource.De
 			WithClusterID(clusterID).
 			WithRoleID(clusterRoleName).
 			WithBody(&sub)
+		r.meta.Log.Debugf(ctx, "unbinding user '%s' group '%s' from cluster role '%s'", sub.UserEmail, sub.Group, clusterRoleName)
 		_, err := r.meta.Client.Project.UnbindUserFromClusterRoleBindingV2(params, r.meta.Auth)
 		if err != nil {
 			resp.Diagnostics.AddError("API Error", fmt.Sprintf("Failed to delete cluster role bindings: %s", common.StringifyResponseError(err)))
@@ -207,23 +232,41 @@ func (r *metakubeClusterRoleBinding) Delete(ctx context.Context, req resource.De
 }
 
 func (r *metakubeClusterRoleBinding) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	parts := strings.Split(req.ID, ":")
+	identity, diags := common.ImportClusterResourceIdentity(ctx, req, "cluster_role_binding_name")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if len(parts) != 3 {
-		resp.Diagnostics.AddError(
-			"Invalid import ID",
-			"please provide resource identifier in format 'project_id:cluster_id:cluster_role_binding_name'",
-		)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), identity.ProjectID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), identity.ClusterID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_role_name"), identity.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), identity.ID)...)
+}
+
+// Update does not call the API, changes of the bound cluster role replace the
+// binding. It keeps the ID and sets the identity of state written before
+// identities were supported.
+func (r *metakubeClusterRoleBinding) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state ClusterRoleBindingModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), parts[0])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), parts[1])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_role_name"), parts[2])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[2])...)
+	plan.ID = state.ID
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, clusterRoleBindingIdentity(&plan))...)
 }
 
-func (r *metakubeClusterRoleBinding) Update(_ context.Context, _ resource.UpdateRequest, _ *resource.UpdateResponse) {
+func clusterRoleBindingIdentity(model *ClusterRoleBindingModel) *common.ClusterResourceIdentityModel {
+	return &common.ClusterResourceIdentityModel{
+		ProjectID: model.ProjectID,
+		ClusterID: model.ClusterID,
+		ID:        model.ClusterRoleName,
+	}
 }
 
 func clusterRoleBindingAlreadyConnected(err error) bool {
@@ -8,6 +8,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
@@ -55,6 +57,9 @@ func metakubeClusterRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"project_id": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -62,6 +67,9 @@ func metakubeClusterRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"cluster_id": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -69,6 +77,9 @@ func metakubeClusterRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"cluster_role_name": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -19,6 +19,7 @@ var (
 	_ resource.Resource                = &metakubeMaintenanceCronJob{}
 	_ resource.ResourceWithConfigure   = &metakubeMaintenanceCronJob{}
 	_ resource.ResourceWithImportState = &metakubeMaintenanceCronJob{}
+	_ resource.ResourceWithIdentity    = &metakubeMaintenanceCronJob{}
 )
 
 func NewMaintenanceCronJob() resource.Resource {
@@ -37,6 +38,10 @@ func (r *metakubeMaintenanceCronJob) Schema(ctx context.Context, _ resource.Sche
 	resp.Schema = MaintenanceCronJobSchema(ctx)
 }
 
+func (r *metakubeMaintenanceCronJob) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = common.ClusterResourceIdentitySchema("Maintenance cron job identifier")
+}
+
 func (r *metakubeMaintenanceCronJob) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -55,6 +60,13 @@ func (r *metakubeMaintenanceCronJob) Configure(_ context.Context, req resource.C
 }
 
 func (r *metakubeMaintenanceCronJob) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemMaintenance)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("create", "maintenance cron job"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan MaintenanceCronJobModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -80,7 +92,7 @@ func (r *metakubeMaintenanceCronJob) Create(ctx context.Context, req resource.Cr
 			return
 		}
 		if projectID == "" {
-			r.meta.Log.Infof("owner project for cluster '%s' is not found", clusterID)
+			r.meta.Log.Infof(ctx, "owner project for cluster '%s' is not found", clusterID)
 			resp.Diagnostics.AddError(
 				"Project not found",
 				fmt.Sprintf("could not find owner project for cluster with id '%s'", clusterID),
@@ -155,9 +167,12 @@ func (r *metakubeMaintenanceCronJob) Create(ctx context.Context, req resource.Cr
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, maintenanceCronJobIdentity(&plan))...)
 }
 
 func (r *metakubeMaintenanceCronJob) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemMaintenance)
+
 	var data MaintenanceCronJobModel
 
 	diags := req.State.Get(ctx, &data)
@@ -178,12 +193,12 @@ func (r *metakubeMaintenanceCronJob) Read(ctx context.Context, req resource.Read
 	result, err := r.meta.Client.Project.GetMaintenanceCronJob(p, r.meta.Auth)
 	if err != nil {
 		if e, ok := err.(*project.GetMaintenanceCronJobDefault); ok && e.Code() == http.StatusNotFound {
-			r.meta.Log.Infof("removing maintenance cron job '%s' from terraform state file, could not find the resource", data.ID.ValueString())
+			r.meta.Log.Infof(ctx, "removing maintenance cron job '%s' from terraform state file, could not find the resource", data.ID.ValueString())
 			resp.State.RemoveResource(ctx)
 			return
 		}
 		if _, ok := err.(*project.GetMaintenanceCronJobForbidden); ok {
-			r.meta.Log.Infof("removing maintenance cron job '%s' from terraform state file, access forbidden", data.ID.ValueString())
+			r.meta.Log.Infof(ctx, "removing maintenance cron job '%s' from terraform state file, access forbidden", data.ID.ValueString())
 			resp.State.RemoveResource(ctx)
 			return
 		}
@@ -203,9 +218,17 @@ func (r *metakubeMaintenanceCronJob) Read(ctx context.Context, req resource.Read
 
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, maintenanceCronJobIdentity(&data))...)
 }
 
 func (r *metakubeMaintenanceCronJob) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemMaintenance)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("update", "maintenance cron job"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan MaintenanceCronJobModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -268,9 +291,17 @@ func (r *metakubeMaintenanceCronJob) Update(ctx context.Context, req resource.Up
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, maintenanceCronJobIdentity(&plan))...)
 }
 
 func (r *metakubeMaintenanceCronJob) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemMaintenance)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("delete", "maintenance cron job"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var state MaintenanceCronJobModel
 
 	diags := req.State.Get(ctx, &state)
@@ -297,7 +328,7 @@ func (r *metakubeMaintenanceCronJob) Delete(ctx context.Context, req resource.De
 	_, err := r.meta.Client.Project.DeleteMaintenanceCronJob(p, r.meta.Auth)
 	if err != nil {
 		if e, ok := err.(*project.DeleteMaintenanceCronJobDefault); ok && e.Code() == http.StatusNotFound {
-			r.meta.Log.Infof("maintenance cron job '%s' already deleted", cronJobID)
+			r.meta.Log.Infof(ctx, "maintenance cron job '%s' already deleted", cronJobID)
 			return
 		}
 		resp.Diagnostics.AddError("API Error", fmt.Sprintf("unable to delete maintenance cron job '%s': %s", cronJobID, common.StringifyResponseError(err)))
@@ -314,13 +345,13 @@ func (r *metakubeMaintenanceCronJob) Delete(ctx context.Context, req resource.De
 		result, err := r.meta.Client.Project.GetMaintenanceCronJob(getP, r.meta.Auth)
 		if err != nil {
 			if e, ok := err.(*project.GetMaintenanceCronJobDefault); ok && e.Code() == http.StatusNotFound {
-				r.meta.Log.Debugf("maintenance cron job '%s' has been destroyed, returned http code: %d", cronJobID, e.Code())
+				r.meta.Log.Debugf(ctx, "maintenance cron job '%s' has been destroyed, returned http code: %d", cronJobID, e.Code())
 				return nil
 			}
 			return common.NonRetryableError(fmt.Errorf("unable to get maintenance cron job '%s': %s", cronJobID, common.StringifyResponseError(err)))
 		}
 
-		r.meta.Log.Debugf("maintenance cron job '%s' deletion in progress, deletionTimestamp: %s",
+		r.meta.Log.Debugf(ctx, "maintenance cron job '%s' deletion in progress, deletionTimestamp: %s",
 			cronJobID, result.Payload.DeletionTimestamp)
 		return common.RetryableError(fmt.Errorf("maintenance cron job '%s' deletion in progress", cronJobID))
 	})
@@ -330,19 +361,23 @@ func (r *metakubeMaintenanceCronJob) Delete(ctx context.Context, req resource.De
 }
 
 func (r *metakubeMaintenanceCronJob) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	parts := strings.Split(req.ID, ":")
-
-	if len(parts) != 3 {
-		resp.Diagnostics.AddError(
-			"Invalid import ID",
-			"please provide resource identifier in format 'project_id:cluster_id:maintenance_cronjob_id'",
-		)
+	identity, diags := common.ImportClusterResourceIdentity(ctx, req, "maintenance_cronjob_id")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), parts[0])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), parts[1])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[2])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), identity.ProjectID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), identity.ClusterID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), identity.ID)...)
+}
+
+func maintenanceCronJobIdentity(model *MaintenanceCronJobModel) *common.ClusterResourceIdentityModel {
+	return &common.ClusterResourceIdentityModel{
+		ProjectID: model.ProjectID,
+		ClusterID: model.ClusterID,
+		ID:        model.ID,
+	}
 }
 
 func metakubeResourceMaintenanceCronJobWaitForReady(ctx context.Context, k *common.MetaKubeProviderMeta, timeout time.Duration, projectID, clusterID, id string) error {
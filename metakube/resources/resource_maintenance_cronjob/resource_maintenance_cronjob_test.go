@@ -6,7 +6,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
@@ -15,17 +14,11 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
 	"github.com/syseleven/go-metakube/client/project"
 	"github.com/syseleven/go-metakube/models"
-	"github.com/syseleven/terraform-provider-metakube/metakube"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common"
-	"github.com/syseleven/terraform-provider-metakube/metakube/common/provider_testutil"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common/testutil"
 )
 
 func TestMain(m *testing.M) {
-	provider_testutil.TestAccProvider = metakube.Provider()
-	provider_testutil.TestAccProviders = map[string]*schema.Provider{
-		"metakube": provider_testutil.TestAccProvider,
-	}
 	resource.TestMain(m)
 }
 
@@ -25,6 +25,8 @@ var (
 	_ resource.Resource                = &nodeDeploymentResource{}
 	_ resource.ResourceWithConfigure   = &nodeDeploymentResource{}
 	_ resource.ResourceWithImportState = &nodeDeploymentResource{}
+	_ resource.ResourceWithModifyPlan  = &nodeDeploymentResource{}
+	_ resource.ResourceWithIdentity    = &nodeDeploymentResource{}
 )
 
 // NewNodeDeployment returns a new node deployment resource for the framework provider
@@ -44,6 +46,10 @@ func (r *nodeDeploymentResource) Schema(ctx context.Context, _ resource.SchemaRe
 	resp.Schema = NodeDeploymentSchema(ctx)
 }
 
+func (r *nodeDeploymentResource) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = common.ClusterResourceIdentitySchema("Node deployment identifier")
+}
+
 func (r *nodeDeploymentResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -61,7 +67,114 @@ func (r *nodeDeploymentResource) Configure(_ context.Context, req resource.Confi
 	r.meta = meta
 }
 
+func (r *nodeDeploymentResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	resp.Diagnostics.Append(r.planDeletionProtection(ctx, req, resp)...)
+
+	// Nothing else to do on destroy.
+	if req.Plan.Raw.IsNull() || r.meta == nil {
+		return
+	}
+
+	var spec types.List
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("spec"), &spec)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	maps, d := labelMaps(ctx, spec)
+	resp.Diagnostics.Append(d...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	labelsAll := types.MapUnknown(types.StringType)
+	if labels := maps[labelsField]; !spec.IsUnknown() && !labels.IsUnknown() {
+		labelsAll, d = types.MapValueFrom(ctx, types.StringType, common.MergeDefaultLabels(r.meta.DefaultLabels, expandStringMap(labels)))
+		resp.Diagnostics.Append(d...)
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("labels_all"), labelsAll)...)
+
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var configured, current types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, kubeletPath, &configured)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, kubeletPath, &current)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(checkKubeletDowngrade(configured, current)...)
+}
+
+// kubeletPath is the path of spec.template.versions.kubelet.
+var kubeletPath = path.Root("spec").AtListIndex(0).AtName("template").AtListIndex(0).AtName("versions").AtListIndex(0).AtName("kubelet")
+
+// checkKubeletDowngrade refuses a configured kubelet older than the one the
+// node deployment runs. That happens when the upgrade_policy of the cluster
+// upgraded a node deployment with a pinned kubelet.
+func checkKubeletDowngrade(configured, current types.String) (result diag.Diagnostics) {
+	if configured.IsNull() || configured.IsUnknown() || current.IsNull() || current.IsUnknown() {
+		return result
+	}
+	want, err := version.NewVersion(configured.ValueString())
+	if err != nil {
+		return result
+	}
+	got, err := version.NewVersion(current.ValueString())
+	if err != nil || !want.LessThan(got) {
+		return result
+	}
+	result.AddAttributeError(
+		kubeletPath,
+		"Kubelet downgrade",
+		fmt.Sprintf("The node deployment runs kubelet %s, the configured version %s would downgrade it. "+
+			"If the upgrade_policy of the cluster upgraded it, remove versions.kubelet so that the node deployment follows the cluster, or set it to %s.",
+			current.ValueString(), configured.ValueString(), current.ValueString()),
+	)
+	return result
+}
+
+// planDeletionProtection refuses to plan the destruction or replacement of a
+// protected node deployment.
+func (r *nodeDeploymentResource) planDeletionProtection(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) (result diag.Diagnostics) {
+	replacedBy, d := common.PlannedReplacement(ctx, resp.Private)
+	result.Append(d...)
+	if result.HasError() || req.State.Raw.IsNull() || (!req.Plan.Raw.IsNull() && replacedBy == "") {
+		return result
+	}
+
+	var state NodeDeploymentModel
+	result.Append(req.State.Get(ctx, &state)...)
+	if result.HasError() {
+		return result
+	}
+
+	if replacedBy == "" {
+		result.Append(common.DeletionProtectionToFrameworkDiagnostics(r.checkDeletionProtection("destroy", &state))...)
+		return result
+	}
+	if err := r.checkDeletionProtection("replace", &state); err != nil {
+		result.AddAttributeError(
+			path.Root("deletion_protection"),
+			"Deletion Protection",
+			fmt.Sprintf("Changing %s requires replacing the node deployment. %s", replacedBy, err),
+		)
+	}
+	return result
+}
+
+func (r *nodeDeploymentResource) checkDeletionProtection(operation string, state *NodeDeploymentModel) error {
+	return r.meta.CheckDeletionProtection(operation, "node deployment", state.Name.ValueString(), state.DeletionProtection.ValueBool(), expandStringMap(state.LabelsAll))
+}
+
 func (r *nodeDeploymentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemNodeDeployment)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("create", "node deployment"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan NodeDeploymentModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -92,6 +205,7 @@ func (r *nodeDeploymentResource) Create(ctx context.Context, req resource.Create
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	applyDefaultLabels(nodeDeploymentSpec, r.meta.DefaultLabels)
 
 	nodeDeployment := &models.NodeDeployment{
 		Name: plan.Name.ValueString(),
@@ -190,7 +304,7 @@ func (r *nodeDeploymentResource) Create(ctx context.Context, req resource.Create
 		return
 	}
 
-	if err := r.waitForReady(ctx, createTimeout, projectID, clusterID, nodeDeploymentID); err != nil {
+	if err := common.MetakubeNodeDeploymentWaitForReady(ctx, r.meta, createTimeout, projectID, clusterID, nodeDeploymentID); err != nil {
 		resp.Diagnostics.AddError("Node deployment not ready", err.Error())
 		return
 	}
@@ -206,9 +320,12 @@ func (r *nodeDeploymentResource) Create(ctx context.Context, req resource.Create
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, nodeDeploymentIdentity(&plan))...)
 }
 
 func (r *nodeDeploymentResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemNodeDeployment)
+
 	var state NodeDeploymentModel
 
 	diags := req.State.Get(ctx, &state)
@@ -229,9 +346,17 @@ func (r *nodeDeploymentResource) Read(ctx context.Context, req resource.ReadRequ
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, nodeDeploymentIdentity(&state))...)
 }
 
 func (r *nodeDeploymentResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemNodeDeployment)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("update", "node deployment"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan, state NodeDeploymentModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -251,6 +376,7 @@ func (r *nodeDeploymentResource) Update(ctx context.Context, req resource.Update
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	applyDefaultLabels(nodeDeploymentSpec, r.meta.DefaultLabels)
 
 	nodeDeployment := &models.NodeDeployment{
 		Spec: nodeDeploymentSpec,
@@ -313,7 +439,7 @@ func (r *nodeDeploymentResource) Update(ctx context.Context, req resource.Update
 		return
 	}
 
-	if err := r.waitForReady(ctx, updateTimeout, projectID, clusterID, nodeDeploymentID); err != nil {
+	if err := common.MetakubeNodeDeploymentWaitForReady(ctx, r.meta, updateTimeout, projectID, clusterID, nodeDeploymentID); err != nil {
 		resp.Diagnostics.AddError("Node deployment not ready", err.Error())
 		return
 	}
@@ -329,9 +455,17 @@ func (r *nodeDeploymentResource) Update(ctx context.Context, req resource.Update
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, nodeDeploymentIdentity(&plan))...)
 }
 
 func (r *nodeDeploymentResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemNodeDeployment)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("delete", "node deployment"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var state NodeDeploymentModel
 
 	diags := req.State.Get(ctx, &state)
@@ -340,6 +474,11 @@ func (r *nodeDeploymentResource) Delete(ctx context.Context, req resource.Delete
 		return
 	}
 
+	resp.Diagnostics.Append(common.DeletionProtectionToFrameworkDiagnostics(r.checkDeletionProtection("delete", &state))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	projectID := state.ProjectID.ValueString()
 	clusterID := state.ClusterID.ValueString()
 	nodeDeploymentID := state.ID.ValueString()
@@ -398,19 +537,23 @@ func (r *nodeDeploymentResource) Delete(ctx context.Context, req resource.Delete
 }
 
 func (r *nodeDeploymentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	parts := strings.Split(req.ID, ":")
-
-	if len(parts) != 3 {
-		resp.Diagnostics.AddError(
-			"Invalid import ID",
-			"please provide resource identifier in format 'project_id:cluster_id:node_deployment_id'",
-		)
+	identity, diags := common.ImportClusterResourceIdentity(ctx, req, "node_deployment_id")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), parts[0])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), parts[1])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[2])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), identity.ProjectID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), identity.ClusterID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), identity.ID)...)
+}
+
+func nodeDeploymentIdentity(model *NodeDeploymentModel) *common.ClusterResourceIdentityModel {
+	return &common.ClusterResourceIdentityModel{
+		ProjectID: model.ProjectID,
+		ClusterID: model.ClusterID,
+		ID:        model.ID,
+	}
 }
 
 // readIntoModel reads the node deployment from the API and updates the model
@@ -429,12 +572,12 @@ func (r *nodeDeploymentResource) readIntoModel(ctx context.Context, model *NodeD
 	resp, err := r.meta.Client.Project.GetMachineDeployment(p, r.meta.Auth)
 	if err != nil {
 		if e, ok := err.(*project.GetMachineDeploymentDefault); ok && e.Code() == http.StatusNotFound {
-			r.meta.Log.Infof("removing node deployment '%s' from terraform state file, could not find the resource", nodeDeploymentID)
+			r.meta.Log.Infof(ctx, "removing node deployment '%s' from terraform state file, could not find the resource", nodeDeploymentID)
 			model.ID = types.StringNull()
 			return result
 		}
 		if _, ok := err.(*project.GetMachineDeploymentForbidden); ok {
-			r.meta.Log.Infof("removing node deployment '%s' from terraform state file, access forbidden", nodeDeploymentID)
+			r.meta.Log.Infof(ctx, "removing node deployment '%s' from terraform state file, access forbidden", nodeDeploymentID)
 			model.ID = types.StringNull()
 			return result
 		}
@@ -445,105 +588,43 @@ func (r *nodeDeploymentResource) readIntoModel(ctx context.Context, model *NodeD
 	nd := resp.Payload
 
 	model.Name = types.StringValue(nd.Name)
+	// deletion_protection is not stored in MetaKube, e.g. imported node deployments start unprotected.
+	if model.DeletionProtection.IsNull() {
+		model.DeletionProtection = types.BoolValue(false)
+	}
 
-	specList, d := flattenNodeDeploymentSpec(ctx, nd.Spec)
+	configured, d := labelMaps(ctx, model.Spec)
+	result.Append(d...)
+
+	// Node labels set in the configuration are user labels even when they match an ignored prefix.
+	filter := r.meta.LabelFilter.WithConfigured(expandStringMap(configured[labelsField]))
+	specList, d := flattenNodeDeploymentSpec(ctx, nd.Spec, filter)
+	result.Append(d...)
+	if result.HasError() {
+		return result
+	}
+	// Labels and tags only added from the provider's default_labels don't belong to the configuration.
+	specList, d = stripDefaultLabels(ctx, specList, configured, r.meta.DefaultLabels)
 	result.Append(d...)
 	if result.HasError() {
 		return result
 	}
 	model.Spec = specList
 
+	var nodeLabels map[string]string
+	if nd.Spec != nil && nd.Spec.Template != nil {
+		nodeLabels = nd.Spec.Template.Labels
+	}
+	labelsAll, systemLabels := filter.SplitLabels(nodeLabels)
+	model.LabelsAll = types.MapValueMust(types.StringType, flattenStringMap(labelsAll))
+	model.SystemLabels = types.MapValueMust(types.StringType, flattenStringMap(systemLabels))
+
 	model.CreationTimestamp = types.StringValue(nd.CreationTimestamp.String())
 	model.DeletionTimestamp = types.StringValue(nd.DeletionTimestamp.String())
 
 	return result
 }
 
-// waitForReady waits for the node deployment to be ready
-func (r *nodeDeploymentResource) waitForReady(ctx context.Context, timeout time.Duration, projectID, clusterID, nodeDeploymentID string) error {
-	deadline := time.Now().Add(timeout)
-
-	for {
-		if time.Now().After(deadline) {
-			return fmt.Errorf("timeout waiting for node deployment '%s' to be ready", nodeDeploymentID)
-		}
-
-		p := project.NewGetMachineDeploymentParams().
-			WithContext(ctx).
-			WithProjectID(projectID).
-			WithClusterID(clusterID).
-			WithMachineDeploymentID(nodeDeploymentID)
-
-		resp, err := r.meta.Client.Project.GetMachineDeployment(p, r.meta.Auth)
-		if err != nil {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(5 * time.Second):
-				continue
-			}
-		}
-
-		nd := resp.Payload
-		if nd.Spec.Replicas == nil || nd.Status == nil ||
-			nd.Status.ReadyReplicas < *nd.Spec.Replicas ||
-			nd.Status.UnavailableReplicas != 0 {
-			r.meta.Log.Debugf("waiting for node deployment '%s' to be ready, %+v", nodeDeploymentID, nd.Status)
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(10 * time.Second):
-				continue
-			}
-		}
-
-		p2 := project.NewListMachineDeploymentNodesParams().
-			WithContext(ctx).
-			WithProjectID(projectID).
-			WithClusterID(clusterID).
-			WithMachineDeploymentID(nodeDeploymentID)
-		nodesResp, err := r.meta.Client.Project.ListMachineDeploymentNodes(p2, r.meta.Auth)
-		if err != nil {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(5 * time.Second):
-				continue
-			}
-		}
-
-		if len(nodesResp.Payload) != int(*nd.Spec.Replicas) {
-			r.meta.Log.Debug("node count mismatch, want %v got %v", *nd.Spec.Replicas, len(nodesResp.Payload))
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(10 * time.Second):
-				continue
-			}
-		}
-
-		allReady := true
-		for _, node := range nodesResp.Payload {
-			if node.Status == nil || node.Status.NodeInfo == nil || node.Status.NodeInfo.KernelVersion == "" {
-				allReady = false
-				break
-			}
-		}
-
-		if !allReady {
-			r.meta.Log.Debug("found not ready node")
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(10 * time.Second):
-				continue
-			}
-		}
-
-		return nil
-	}
-}
-
 // validateProviderMatchesCluster validates that the node deployment cloud provider matches the cluster
 func (r *nodeDeploymentResource) validateProviderMatchesCluster(ctx context.Context, projectID, clusterID string, model *NodeDeploymentModel) (result diag.Diagnostics) {
 	cluster, _, err := common.MetakubeGetCluster(ctx, projectID, clusterID, r.meta)
@@ -696,8 +777,14 @@ func (r *nodeDeploymentResource) buildPatchWithDeletions(ctx context.Context, pl
 			planTmpl := planTemplateModels[0]
 			stateTmpl := stateTemplateModels[0]
 
-			if !planTmpl.Labels.Equal(stateTmpl.Labels) {
-				labelsPatch := buildMapPatchFromTypes(planTmpl.Labels, stateTmpl.Labels)
+			// Compare the labels including defaults, so removed default labels are deleted as well.
+			planLabels := types.MapValueMust(types.StringType, flattenStringMap(common.MergeDefaultLabels(r.meta.DefaultLabels, expandStringMap(planTmpl.Labels))))
+			stateLabels := stateTmpl.Labels
+			if !state.LabelsAll.IsNull() && !state.LabelsAll.IsUnknown() {
+				stateLabels = state.LabelsAll
+			}
+			if !planLabels.Equal(stateLabels) {
+				labelsPatch := buildMapPatchFromTypes(planLabels, stateLabels)
 				jsonKey := getJSONKeyForField(reflect.TypeOf(models.NodeSpec{}), "Labels")
 				if jsonKey != "" {
 					templatePatch[jsonKey] = labelsPatch
@@ -719,6 +806,10 @@ func (r *nodeDeploymentResource) buildPatchWithDeletions(ctx context.Context, pl
 					templatePatch[jsonKey] = annoPatch
 				}
 			}
+
+			if err := r.addTagsPatch(ctx, templatePatch, plan.Spec, state.Spec); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -727,6 +818,36 @@ func (r *nodeDeploymentResource) buildPatchWithDeletions(ctx context.Context, pl
 	}, nil
 }
 
+// addTagsPatch adds null values for deleted instance tags to the cloud patch
+// of templatePatch. Both sides include the default labels, the state keeps
+// tags of removed default labels as the refresh no longer strips them.
+func (r *nodeDeploymentResource) addTagsPatch(ctx context.Context, templatePatch map[string]interface{}, planSpec, stateSpec types.List) error {
+	cloudPatch, ok := templatePatch[getJSONKeyForField(reflect.TypeOf(models.NodeSpec{}), "Cloud")].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	planMaps, diags := labelMaps(ctx, planSpec)
+	stateMaps, d := labelMaps(ctx, stateSpec)
+	diags.Append(d...)
+	if diags.HasError() {
+		return fmt.Errorf("read instance tags: %v", diags)
+	}
+
+	for field, provider := range map[string]string{awsTagsField: "Aws", openstackTagsField: "Openstack"} {
+		providerPatch, ok := cloudPatch[getJSONKeyForField(reflect.TypeOf(models.NodeCloudSpec{}), provider)].(map[string]interface{})
+		if !ok || planMaps[field].IsUnknown() || stateMaps[field].IsUnknown() {
+			continue
+		}
+		planTags := types.MapValueMust(types.StringType, flattenStringMap(common.MergeDefaultLabels(r.meta.DefaultLabels, expandStringMap(planMaps[field]))))
+		stateTags := types.MapValueMust(types.StringType, flattenStringMap(common.MergeDefaultLabels(r.meta.DefaultLabels, expandStringMap(stateMaps[field]))))
+		if !planTags.Equal(stateTags) {
+			providerPatch["tags"] = buildMapPatchFromTypes(planTags, stateTags)
+		}
+	}
+	return nil
+}
+
 // buildMapPatchFromTypes builds a patch map from plan and state types.Map
 func buildMapPatchFromTypes(planMap, stateMap types.Map) map[string]interface{} {
 	result := make(map[string]interface{})
@@ -0,0 +1,108 @@
+package resource_node_deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+	"k8s.io/utils/ptr"
+)
+
+func TestCheckKubeletDowngrade(t *testing.T) {
+	cases := []struct {
+		name        string
+		configured  types.String
+		current     types.String
+		expectError bool
+	}{
+		{
+			name:       "not configured",
+			configured: types.StringNull(),
+			current:    types.StringValue("1.31.5"),
+		},
+		{
+			name:       "unknown",
+			configured: types.StringUnknown(),
+			current:    types.StringValue("1.31.5"),
+		},
+		{
+			name:       "created",
+			configured: types.StringValue("1.31.5"),
+			current:    types.StringNull(),
+		},
+		{
+			name:       "unchanged",
+			configured: types.StringValue("1.31.5"),
+			current:    types.StringValue("1.31.5"),
+		},
+		{
+			name:       "upgrade",
+			configured: types.StringValue("1.31.5"),
+			current:    types.StringValue("1.30.9"),
+		},
+		{
+			name:        "upgraded by the cluster",
+			configured:  types.StringValue("1.30.9"),
+			current:     types.StringValue("1.31.5"),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := checkKubeletDowngrade(tc.configured, tc.current)
+			if diags.HasError() != tc.expectError {
+				t.Fatalf("expected error %v, got %v", tc.expectError, diags)
+			}
+		})
+	}
+}
+
+func TestBuildPatchWithDeletionsRemovedDefaultTag(t *testing.T) {
+	ctx := context.Background()
+
+	newSpec := func(tags map[string]string) *models.NodeDeploymentSpec {
+		return &models.NodeDeploymentSpec{
+			Replicas: ptr.To(int32(1)),
+			Template: &models.NodeSpec{
+				Cloud: &models.NodeCloudSpec{
+					Openstack: &models.OpenstackNodeSpec{
+						Flavor: ptr.To("m1.small"),
+						Image:  ptr.To("Ubuntu 22.04"),
+						Tags:   tags,
+					},
+				},
+			},
+		}
+	}
+	model := func(spec *models.NodeDeploymentSpec) *NodeDeploymentModel {
+		flattened, diags := flattenNodeDeploymentSpec(ctx, spec, nil)
+		if diags.HasError() {
+			t.Fatalf("flatten failed: %v", diags)
+		}
+		return &NodeDeploymentModel{Spec: flattened, LabelsAll: types.MapNull(types.StringType)}
+	}
+
+	// team was a default label before, the refresh keeps it in the state as it is no default anymore.
+	defaults := map[string]string{"env": "prod"}
+	state := model(newSpec(map[string]string{"app": "web", "team": "platform"}))
+	plan := model(newSpec(map[string]string{"app": "web"}))
+	nd := &models.NodeDeployment{Spec: newSpec(map[string]string{"app": "web"})}
+	applyDefaultLabels(nd.Spec, defaults)
+
+	r := &nodeDeploymentResource{meta: &common.MetaKubeProviderMeta{DefaultLabels: defaults}}
+	patch, err := r.buildPatchWithDeletions(ctx, plan, state, nd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	template := patch["spec"].(map[string]interface{})["template"].(map[string]interface{})
+	openstack := template["cloud"].(map[string]interface{})["openstack"].(map[string]interface{})
+	want := map[string]interface{}{"app": "web", "env": "prod", "team": nil}
+	if diff := cmp.Diff(want, openstack["tags"]); diff != "" {
+		t.Errorf("tags patch mismatch (-want +got):\n%s", diff)
+	}
+}
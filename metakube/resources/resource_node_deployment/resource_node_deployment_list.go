@@ -0,0 +1,139 @@
+package resource_node_deployment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/go-metakube/client/project"
+	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+var (
+	_ list.ListResource              = &nodeDeploymentResource{}
+	_ list.ListResourceWithConfigure = &nodeDeploymentResource{}
+)
+
+func NewNodeDeploymentListResource() list.ListResource {
+	return &nodeDeploymentResource{}
+}
+
+type nodeDeploymentListModel struct {
+	ProjectID types.String `tfsdk:"project_id"`
+	ClusterID types.String `tfsdk:"cluster_id"`
+}
+
+func (r *nodeDeploymentResource) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the node deployments of a cluster, for example to generate import blocks with terraform query",
+		Attributes: map[string]schema.Attribute{
+			"project_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Project the cluster belongs to, looked up by cluster_id when omitted",
+			},
+			"cluster_id": schema.StringAttribute{
+				Required:    true,
+				Description: "Cluster to list node deployments of",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+		},
+	}
+}
+
+func (r *nodeDeploymentResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	if r.meta == nil {
+		stream.Results = list.ListResultsStreamDiagnostics(common.UnconfiguredProviderDiagnostics())
+		return
+	}
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemNodeDeployment)
+
+	var config nodeDeploymentListModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	clusterID := config.ClusterID.ValueString()
+	projectID := config.ProjectID.ValueString()
+	if projectID == "" {
+		var err error
+		projectID, err = common.MetakubeResourceClusterFindProjectID(ctx, clusterID, r.meta)
+		if err != nil {
+			diags.AddError("Failed to find project", fmt.Sprintf("Could not find project for cluster %s: %v", clusterID, err))
+			stream.Results = list.ListResultsStreamDiagnostics(diags)
+			return
+		}
+		if projectID == "" {
+			diags.AddError("Project not found", fmt.Sprintf("Could not find owner project for cluster with id '%s'", clusterID))
+			stream.Results = list.ListResultsStreamDiagnostics(diags)
+			return
+		}
+	}
+
+	p := project.NewListMachineDeploymentsParams().
+		WithContext(ctx).
+		WithProjectID(projectID).
+		WithClusterID(clusterID)
+	res, err := r.meta.Client.Project.ListMachineDeployments(p, r.meta.Auth)
+	if err != nil {
+		diags.AddError(
+			"Unable to list node deployments",
+			fmt.Sprintf("Unable to list node deployments of cluster '%s/%s': %s", projectID, clusterID, common.StringifyResponseError(err)),
+		)
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		for i, nd := range res.Payload {
+			if req.Limit > 0 && int64(i) >= req.Limit {
+				return
+			}
+			if !push(r.nodeDeploymentListResult(ctx, req, projectID, clusterID, nd)) {
+				return
+			}
+		}
+	}
+}
+
+// nodeDeploymentListResult returns the identity of a listed node deployment
+// and, when requested, reads the full resource the same way an import does.
+func (r *nodeDeploymentResource) nodeDeploymentListResult(ctx context.Context, req list.ListRequest, projectID, clusterID string, nd *models.NodeDeployment) list.ListResult {
+	result := req.NewListResult(ctx)
+	result.DisplayName = nd.Name
+
+	result.Diagnostics.Append(result.Identity.Set(ctx, &common.ClusterResourceIdentityModel{
+		ProjectID: types.StringValue(projectID),
+		ClusterID: types.StringValue(clusterID),
+		ID:        types.StringValue(nd.ID),
+	})...)
+	if !req.IncludeResource || result.Diagnostics.HasError() {
+		return result
+	}
+
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("cluster_id"), clusterID)...)
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("id"), nd.ID)...)
+	var model NodeDeploymentModel
+	result.Diagnostics.Append(result.Resource.Get(ctx, &model)...)
+	if result.Diagnostics.HasError() {
+		return result
+	}
+
+	result.Diagnostics.Append(r.readIntoModel(ctx, &model)...)
+	if result.Diagnostics.HasError() {
+		return result
+	}
+	result.Diagnostics.Append(result.Resource.Set(ctx, &model)...)
+
+	return result
+}
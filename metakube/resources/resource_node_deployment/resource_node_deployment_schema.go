@@ -17,17 +17,21 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
 )
 
 type NodeDeploymentModel struct {
-	ID                types.String   `tfsdk:"id"`
-	ProjectID         types.String   `tfsdk:"project_id"`
-	ClusterID         types.String   `tfsdk:"cluster_id"`
-	Name              types.String   `tfsdk:"name"`
-	Spec              types.List     `tfsdk:"spec"`
-	CreationTimestamp types.String   `tfsdk:"creation_timestamp"`
-	DeletionTimestamp types.String   `tfsdk:"deletion_timestamp"`
-	Timeouts          timeouts.Value `tfsdk:"timeouts"`
+	ID                 types.String   `tfsdk:"id"`
+	ProjectID          types.String   `tfsdk:"project_id"`
+	ClusterID          types.String   `tfsdk:"cluster_id"`
+	Name               types.String   `tfsdk:"name"`
+	Spec               types.List     `tfsdk:"spec"`
+	LabelsAll          types.Map      `tfsdk:"labels_all"`
+	SystemLabels       types.Map      `tfsdk:"system_labels"`
+	DeletionProtection types.Bool     `tfsdk:"deletion_protection"`
+	CreationTimestamp  types.String   `tfsdk:"creation_timestamp"`
+	DeletionTimestamp  types.String   `tfsdk:"deletion_timestamp"`
+	Timeouts           timeouts.Value `tfsdk:"timeouts"`
 }
 
 type NodeDeploymentSpecModel struct {
@@ -225,7 +229,7 @@ func nodeDeploymentAttributes() map[string]schema.Attribute {
 			Required:    true,
 			Description: "Cluster that node deployment belongs to",
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				common.RequiresReplace(),
 			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
@@ -236,10 +240,26 @@ func nodeDeploymentAttributes() map[string]schema.Attribute {
 			Computed:    true,
 			Description: "Node deployment name",
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				common.RequiresReplace(),
 				stringplanmodifier.UseStateForUnknown(),
 			},
 		},
+		"labels_all": schema.MapAttribute{
+			Computed:    true,
+			ElementType: types.StringType,
+			Description: "Labels applied to the nodes, including the provider's default_labels. System labels are available in `all_labels` of the template.",
+		},
+		"system_labels": schema.MapAttribute{
+			Computed:    true,
+			ElementType: types.StringType,
+			Description: "Node labels managed by MetaKube, selected by the provider's ignore_label_prefixes and ignore_label_keys",
+		},
+		"deletion_protection": schema.BoolAttribute{
+			Optional:    true,
+			Computed:    true,
+			Description: "Refuse to destroy or replace the node deployment while set. Also implied by the provider's protect_labels",
+			Default:     booldefault.StaticBool(false),
+		},
 		"creation_timestamp": schema.StringAttribute{
 			Computed:    true,
 			Description: "Creation timestamp",
@@ -570,6 +590,9 @@ func versionsAttributes() map[string]schema.Attribute {
 			Optional:    true,
 			Computed:    true,
 			Description: "Kubelet version",
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
 		},
 	}
 }
@@ -675,13 +698,3 @@ func (m int64RequiresReplacePlanModifier) PlanModifyInt64(ctx context.Context, r
 		resp.RequiresReplace = true
 	}
 }
-
-// isSystemKey checks for labels created by Metakube.
-func isSystemKey(key string) bool {
-	switch key {
-	case "system/cluster", "system/project":
-		return true
-	default:
-		return false
-	}
-}
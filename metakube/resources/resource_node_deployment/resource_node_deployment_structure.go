@@ -13,7 +13,7 @@ import (
 
 // Framework flatten functions - convert API models to framework types
 
-func flattenNodeDeploymentSpec(ctx context.Context, in *models.NodeDeploymentSpec) (types.List, diag.Diagnostics) {
+func flattenNodeDeploymentSpec(ctx context.Context, in *models.NodeDeploymentSpec, filter *common.LabelFilter) (types.List, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
 	if in == nil {
@@ -41,7 +41,7 @@ func flattenNodeDeploymentSpec(ctx context.Context, in *models.NodeDeploymentSpe
 	}
 
 	if in.Template != nil {
-		templateList, d := flattenNodeSpec(ctx, in.Template)
+		templateList, d := flattenNodeSpec(ctx, in.Template, filter)
 		diags.Append(d...)
 		specModel.Template = templateList
 	} else {
@@ -60,7 +60,7 @@ func flattenNodeDeploymentSpec(ctx context.Context, in *models.NodeDeploymentSpe
 	return specList, diags
 }
 
-func flattenNodeSpec(ctx context.Context, in *models.NodeSpec) (types.List, diag.Diagnostics) {
+func flattenNodeSpec(ctx context.Context, in *models.NodeSpec, filter *common.LabelFilter) (types.List, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
 	if in == nil {
@@ -76,7 +76,7 @@ func flattenNodeSpec(ctx context.Context, in *models.NodeSpec) (types.List, diag
 
 		for k, v := range in.Labels {
 			allLabelsMap[k] = types.StringValue(v)
-			if !isSystemKey(k) {
+			if !filter.IsSystemLabel(k) {
 				userLabelsMap[k] = types.StringValue(v)
 			}
 		}
@@ -946,3 +946,156 @@ func getCloudProviderFromModel(ctx context.Context, model *NodeDeploymentModel)
 
 	return "", diags
 }
+
+// Maps of a node spec that the provider's default labels are merged into.
+const (
+	labelsField        = "labels"
+	awsTagsField       = "aws.tags"
+	openstackTagsField = "openstack.tags"
+)
+
+// applyDefaultLabels merges the provider's default labels into the node labels
+// and instance tags of spec.
+func applyDefaultLabels(spec *models.NodeDeploymentSpec, defaults map[string]string) {
+	if len(defaults) == 0 || spec == nil || spec.Template == nil {
+		return
+	}
+
+	tmpl := spec.Template
+	tmpl.Labels = common.MergeDefaultLabels(defaults, tmpl.Labels)
+	if tmpl.Cloud == nil {
+		return
+	}
+	if tmpl.Cloud.Aws != nil {
+		tmpl.Cloud.Aws.Tags = common.MergeDefaultLabels(defaults, tmpl.Cloud.Aws.Tags)
+	}
+	if tmpl.Cloud.Openstack != nil {
+		tmpl.Cloud.Openstack.Tags = common.MergeDefaultLabels(defaults, tmpl.Cloud.Openstack.Tags)
+	}
+}
+
+// labelMaps returns the node labels and instance tags of spec keyed by field.
+func labelMaps(ctx context.Context, spec types.List) (map[string]types.Map, diag.Diagnostics) {
+	result := make(map[string]types.Map)
+	_, diags := transformLabelMaps(ctx, spec, func(field string, m types.Map) types.Map {
+		result[field] = m
+		return m
+	})
+	return result, diags
+}
+
+// stripDefaultLabels removes the labels and tags from spec that were only added
+// from the provider's default labels, configured holds the maps of the configuration.
+func stripDefaultLabels(ctx context.Context, spec types.List, configured map[string]types.Map, defaults map[string]string) (types.List, diag.Diagnostics) {
+	if len(defaults) == 0 {
+		return spec, nil
+	}
+
+	return transformLabelMaps(ctx, spec, func(field string, m types.Map) types.Map {
+		if m.IsNull() || m.IsUnknown() {
+			return m
+		}
+		stripped := common.StripDefaultLabels(defaults, expandStringMap(m), expandStringMap(configured[field]))
+		if len(stripped) == 0 {
+			return types.MapNull(types.StringType)
+		}
+		return types.MapValueMust(types.StringType, flattenStringMap(stripped))
+	})
+}
+
+// transformLabelMaps returns spec with its node labels and instance tags replaced by fn.
+func transformLabelMaps(ctx context.Context, spec types.List, fn func(field string, m types.Map) types.Map) (types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if spec.IsNull() || spec.IsUnknown() || len(spec.Elements()) == 0 {
+		return spec, diags
+	}
+
+	var specModels []NodeDeploymentSpecModel
+	diags.Append(spec.ElementsAs(ctx, &specModels, false)...)
+	if diags.HasError() || len(specModels) == 0 {
+		return spec, diags
+	}
+
+	if specModels[0].Template.IsNull() || specModels[0].Template.IsUnknown() || len(specModels[0].Template.Elements()) == 0 {
+		return spec, diags
+	}
+
+	var templateModels []NodeSpecModel
+	diags.Append(specModels[0].Template.ElementsAs(ctx, &templateModels, false)...)
+	if diags.HasError() || len(templateModels) == 0 {
+		return spec, diags
+	}
+
+	tmpl := &templateModels[0]
+	tmpl.Labels = fn(labelsField, tmpl.Labels)
+
+	if !tmpl.Cloud.IsNull() && !tmpl.Cloud.IsUnknown() && len(tmpl.Cloud.Elements()) > 0 {
+		var cloudModels []CloudSpecModel
+		diags.Append(tmpl.Cloud.ElementsAs(ctx, &cloudModels, false)...)
+		if diags.HasError() || len(cloudModels) == 0 {
+			return spec, diags
+		}
+		cloud := &cloudModels[0]
+
+		if !cloud.AWS.IsNull() && !cloud.AWS.IsUnknown() && len(cloud.AWS.Elements()) > 0 {
+			var awsModels []AWSCloudSpecModel
+			diags.Append(cloud.AWS.ElementsAs(ctx, &awsModels, false)...)
+			if diags.HasError() {
+				return spec, diags
+			}
+			awsModels[0].Tags = fn(awsTagsField, awsModels[0].Tags)
+			var d diag.Diagnostics
+			cloud.AWS, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: awsCloudSpecAttrTypes()}, awsModels)
+			diags.Append(d...)
+		}
+
+		if !cloud.OpenStack.IsNull() && !cloud.OpenStack.IsUnknown() && len(cloud.OpenStack.Elements()) > 0 {
+			var osModels []OpenStackCloudSpecModel
+			diags.Append(cloud.OpenStack.ElementsAs(ctx, &osModels, false)...)
+			if diags.HasError() {
+				return spec, diags
+			}
+			osModels[0].Tags = fn(openstackTagsField, osModels[0].Tags)
+			var d diag.Diagnostics
+			cloud.OpenStack, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: openstackCloudSpecAttrTypes()}, osModels)
+			diags.Append(d...)
+		}
+
+		var d diag.Diagnostics
+		tmpl.Cloud, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: cloudSpecAttrTypes()}, cloudModels)
+		diags.Append(d...)
+	}
+
+	var d diag.Diagnostics
+	specModels[0].Template, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: nodeSpecAttrTypes()}, templateModels)
+	diags.Append(d...)
+	if diags.HasError() {
+		return spec, diags
+	}
+
+	result, d := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: nodeDeploymentSpecAttrTypes()}, specModels)
+	diags.Append(d...)
+	return result, diags
+}
+
+func expandStringMap(m types.Map) map[string]string {
+	result := make(map[string]string)
+	if m.IsNull() || m.IsUnknown() {
+		return result
+	}
+	for k, v := range m.Elements() {
+		if strVal, ok := v.(types.String); ok && !strVal.IsNull() && !strVal.IsUnknown() {
+			result[k] = strVal.ValueString()
+		}
+	}
+	return result
+}
+
+func flattenStringMap(m map[string]string) map[string]attr.Value {
+	result := make(map[string]attr.Value, len(m))
+	for k, v := range m {
+		result[k] = types.StringValue(v)
+	}
+	return result
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
 	"k8s.io/utils/ptr"
 )
 
@@ -44,7 +45,7 @@ func TestFrameworkFlattenNodeDeploymentSpec(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, diags := flattenNodeDeploymentSpec(ctx, tt.input)
+			result, diags := flattenNodeDeploymentSpec(ctx, tt.input, nil)
 			if diags.HasError() {
 				t.Fatalf("unexpected errors: %v", diags)
 			}
@@ -168,7 +169,7 @@ func TestFlattenAndExpandRoundTrip(t *testing.T) {
 	}
 
 	// Flatten
-	flattenedList, diags := flattenNodeDeploymentSpec(ctx, originalSpec)
+	flattenedList, diags := flattenNodeDeploymentSpec(ctx, originalSpec, nil)
 	if diags.HasError() {
 		t.Fatalf("flatten failed: %v", diags)
 	}
@@ -343,3 +344,128 @@ func buildMockNodeDeploymentModel(ctx context.Context, t *testing.T, cloudModel
 		DeletionTimestamp: types.StringNull(),
 	}
 }
+
+func TestDefaultLabelsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	defaults := map[string]string{"team": "platform", "env": "prod"}
+	newSpec := func() *models.NodeDeploymentSpec {
+		return &models.NodeDeploymentSpec{
+			Replicas: ptr.To(int32(1)),
+			Template: &models.NodeSpec{
+				Labels: map[string]string{"env": "dev", "team": "platform"},
+				Cloud: &models.NodeCloudSpec{
+					Openstack: &models.OpenstackNodeSpec{
+						Flavor: ptr.To("m1.small"),
+						Image:  ptr.To("Ubuntu 22.04"),
+						Tags:   map[string]string{"app": "web"},
+					},
+				},
+			},
+		}
+	}
+
+	// The configuration sets env, overriding the default, and team with the default value.
+	configuredSpec, diags := flattenNodeDeploymentSpec(ctx, newSpec(), nil)
+	if diags.HasError() {
+		t.Fatalf("flatten failed: %v", diags)
+	}
+	configured, diags := labelMaps(ctx, configuredSpec)
+	if diags.HasError() {
+		t.Fatalf("label maps failed: %v", diags)
+	}
+
+	applied := newSpec()
+	applyDefaultLabels(applied, defaults)
+	wantLabels := map[string]string{"env": "dev", "team": "platform"}
+	if diff := cmp.Diff(wantLabels, applied.Template.Labels); diff != "" {
+		t.Errorf("labels mismatch (-want +got):\n%s", diff)
+	}
+	wantTags := map[string]string{"app": "web", "env": "prod", "team": "platform"}
+	if diff := cmp.Diff(wantTags, applied.Template.Cloud.Openstack.Tags); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+
+	flattened, diags := flattenNodeDeploymentSpec(ctx, applied, nil)
+	if diags.HasError() {
+		t.Fatalf("flatten failed: %v", diags)
+	}
+	stripped, diags := stripDefaultLabels(ctx, flattened, configured, defaults)
+	if diags.HasError() {
+		t.Fatalf("strip failed: %v", diags)
+	}
+	if !stripped.Equal(configuredSpec) {
+		t.Errorf("expected the configured spec after stripping default labels, got %s", stripped)
+	}
+}
+
+func TestFlattenNodeSpecSystemLabels(t *testing.T) {
+	ctx := context.Background()
+
+	in := &models.NodeSpec{
+		Labels: map[string]string{
+			"system/cluster":                   "abc",
+			"node.kubernetes.io/instance-type": "m1.small",
+			"team-metakube-platform":           "yes",
+			"kubernetes.io-team":               "infra",
+			"custom/ignored":                   "x",
+			"node-role.kubernetes.io/worker":   "",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		filter *common.LabelFilter
+		want   map[string]string
+	}{
+		{
+			name:   "default prefixes",
+			filter: nil,
+			want: map[string]string{
+				"team-metakube-platform": "yes",
+				"kubernetes.io-team":     "infra",
+				"custom/ignored":         "x",
+			},
+		},
+		{
+			name:   "configured prefixes and keys",
+			filter: common.NewLabelFilter([]string{"system/"}, []string{"custom/ignored"}),
+			want: map[string]string{
+				"node.kubernetes.io/instance-type": "m1.small",
+				"node-role.kubernetes.io/worker":   "",
+				"team-metakube-platform":           "yes",
+				"kubernetes.io-team":               "infra",
+			},
+		},
+		{
+			name:   "configured labels matching default prefixes",
+			filter: common.NewLabelFilter(nil, nil).WithConfigured(map[string]string{"node-role.kubernetes.io/worker": ""}),
+			want: map[string]string{
+				"node-role.kubernetes.io/worker": "",
+				"team-metakube-platform":         "yes",
+				"kubernetes.io-team":             "infra",
+				"custom/ignored":                 "x",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, diags := flattenNodeSpec(ctx, in, tt.filter)
+			if diags.HasError() {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+
+			var templates []NodeSpecModel
+			if diags := result.ElementsAs(ctx, &templates, false); diags.HasError() {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+			if diff := cmp.Diff(tt.want, expandStringMap(templates[0].Labels)); diff != "" {
+				t.Errorf("labels mismatch (-want +got):\n%s", diff)
+			}
+			if got := len(templates[0].AllLabels.Elements()); got != len(in.Labels) {
+				t.Errorf("expected %d labels in all_labels, got %d", len(in.Labels), got)
+			}
+		})
+	}
+}
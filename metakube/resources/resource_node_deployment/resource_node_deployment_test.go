@@ -7,7 +7,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
 	"github.com/hashicorp/terraform-plugin-testing/plancheck"
@@ -16,17 +15,11 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 	"github.com/syseleven/go-metakube/client/project"
 	"github.com/syseleven/go-metakube/models"
-	"github.com/syseleven/terraform-provider-metakube/metakube"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common"
-	"github.com/syseleven/terraform-provider-metakube/metakube/common/provider_testutil"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common/testutil"
 )
 
 func TestMain(m *testing.M) {
-	provider_testutil.TestAccProvider = metakube.Provider()
-	provider_testutil.TestAccProviders = map[string]*schema.Provider{
-		"metakube": provider_testutil.TestAccProvider,
-	}
 	resource.TestMain(m)
 }
 
@@ -238,6 +231,11 @@ func TestAccMetakubeNodeDeployment_Openstack_Basic(t *testing.T) {
 					return "", fmt.Errorf("not found")
 				},
 			},
+			{
+				ResourceName:    resourceName,
+				ImportState:     true,
+				ImportStateKind: resource.ImportBlockWithResourceIdentity,
+			},
 			// Test importing non-existent resource provides expected error.
 			{
 				ResourceName:      resourceName,
@@ -18,6 +18,7 @@ var (
 	_ resource.Resource                = &metakubeRoleBinding{}
 	_ resource.ResourceWithConfigure   = &metakubeRoleBinding{}
 	_ resource.ResourceWithImportState = &metakubeRoleBinding{}
+	_ resource.ResourceWithIdentity    = &metakubeRoleBinding{}
 )
 
 func NewRoleBinding() resource.Resource {
@@ -36,6 +37,10 @@ func (r *metakubeRoleBinding) Schema(ctx context.Context, _ resource.SchemaReque
 	resp.Schema = RoleBindingSchema(ctx)
 }
 
+func (r *metakubeRoleBinding) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = roleBindingIdentitySchema()
+}
+
 func (r *metakubeRoleBinding) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -54,6 +59,8 @@ func (r *metakubeRoleBinding) Configure(_ context.Context, req resource.Configur
 }
 
 func (r *metakubeRoleBinding) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemRBAC)
+
 	var data RoleBindingModel
 
 	diags := req.State.Get(ctx, &data)
@@ -88,6 +95,7 @@ func (r *metakubeRoleBinding) Read(ctx context.Context, req resource.ReadRequest
 
 			diags = resp.State.Set(ctx, &data)
 			resp.Diagnostics.Append(diags...)
+			resp.Diagnostics.Append(resp.Identity.Set(ctx, roleBindingIdentity(&data))...)
 
 			return
 		}
@@ -99,6 +107,13 @@ func (r *metakubeRoleBinding) Read(ctx context.Context, req resource.ReadRequest
 }
 
 func (r *metakubeRoleBinding) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemRBAC)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("create", "role binding"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var plan RoleBindingModel
 
 	diags := req.Plan.Get(ctx, &plan)
@@ -155,6 +170,7 @@ func (r *metakubeRoleBinding) Create(ctx context.Context, req resource.CreateReq
 
 			e, ok := err.(*project.BindUserToRoleV2Default)
 			if ok && (e.Code() == http.StatusConflict || e.Code() == http.StatusNotFound) {
+				r.meta.Log.Debugf(ctx, "cluster '%s' not ready to bind role '%s' in namespace '%s', retrying: %s", clusterID, roleName, namespace, common.StringifyResponseError(err))
 				lastErr = err
 				select {
 				case <-ctx.Done():
@@ -178,9 +194,17 @@ func (r *metakubeRoleBinding) Create(ctx context.Context, req resource.CreateReq
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, roleBindingIdentity(&plan))...)
 }
 
 func (r *metakubeRoleBinding) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemRBAC)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("delete", "role binding"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	var state RoleBindingModel
 
 	diags := req.State.Get(ctx, &state)
@@ -204,6 +228,7 @@ func (r *metakubeRoleBinding) Delete(ctx context.Context, req resource.DeleteReq
 			WithNamespace(namespace).
 			WithRoleID(roleName).
 			WithBody(&sub)
+		r.meta.Log.Debugf(ctx, "unbinding user '%s' group '%s' from role '%s' in namespace '%s'", sub.UserEmail, sub.Group, roleName, namespace)
 		_, err := r.meta.Client.Project.UnbindUserFromRoleBindingV2(params, r.meta.Auth)
 		if err != nil {
 			resp.Diagnostics.AddError("API Error", fmt.Sprintf("Failed to delete role bindings: %s", common.StringifyResponseError(err)))
@@ -212,25 +237,54 @@ func (r *metakubeRoleBinding) Delete(ctx context.Context, req resource.DeleteReq
 	}
 }
 
+// ImportState accepts an identity or the legacy import ID
+// 'project_id:cluster_id:role_namespace:role_name'.
 func (r *metakubeRoleBinding) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	parts := strings.Split(req.ID, ":")
+	var identity roleBindingIdentityModel
+	if req.ID == "" {
+		resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	} else {
+		parts := strings.Split(req.ID, ":")
+		if len(parts) != 4 {
+			resp.Diagnostics.AddError(
+				"Invalid import ID",
+				"please provide resource identifier in format 'project_id:cluster_id:role_namespace:role_name'",
+			)
+			return
+		}
+		identity = roleBindingIdentityModel{
+			ProjectID: types.StringValue(parts[0]),
+			ClusterID: types.StringValue(parts[1]),
+			Namespace: types.StringValue(parts[2]),
+			RoleName:  types.StringValue(parts[3]),
+		}
+	}
 
-	if len(parts) != 4 {
-		resp.Diagnostics.AddError(
-			"Invalid import ID",
-			"please provide resource identifier in format 'project_id:cluster_id:role_namespace:role_name'",
-		)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), identity.ProjectID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), identity.ClusterID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("namespace"), identity.Namespace)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("role_name"), identity.RoleName)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), identity.Namespace.ValueString()+":"+identity.RoleName.ValueString())...)
+}
+
+// Update does not call the API, changes of the bound role replace the binding.
+// It keeps the ID and sets the identity of state written before identities
+// were supported.
+func (r *metakubeRoleBinding) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state RoleBindingModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), parts[0])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cluster_id"), parts[1])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("namespace"), parts[2])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("role_name"), parts[3])...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[2]+":"+parts[3])...)
-}
+	plan.ID = state.ID
 
-func (r *metakubeRoleBinding) Update(_ context.Context, _ resource.UpdateRequest, _ *resource.UpdateResponse) {
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, roleBindingIdentity(&plan))...)
 }
 
 func roleBindingAlreadyConnected(err error) bool {
@@ -7,7 +7,10 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
@@ -24,6 +27,47 @@ func RoleBindingSchema(ctx context.Context) schema.Schema {
 	}
 }
 
+// roleBindingIdentitySchema identifies a role binding by its namespace and role
+// instead of the combined id.
+func roleBindingIdentitySchema() identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"project_id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "Project the cluster belongs to",
+			},
+			"cluster_id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "Cluster the role binding belongs to",
+			},
+			"namespace": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "The name of the namespace",
+			},
+			"role_name": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "The name of the bound role",
+			},
+		},
+	}
+}
+
+type roleBindingIdentityModel struct {
+	ProjectID types.String `tfsdk:"project_id"`
+	ClusterID types.String `tfsdk:"cluster_id"`
+	Namespace types.String `tfsdk:"namespace"`
+	RoleName  types.String `tfsdk:"role_name"`
+}
+
+func roleBindingIdentity(model *RoleBindingModel) *roleBindingIdentityModel {
+	return &roleBindingIdentityModel{
+		ProjectID: model.ProjectID,
+		ClusterID: model.ClusterID,
+		Namespace: model.Namespace,
+		RoleName:  model.RoleName,
+	}
+}
+
 // RoleBindingModel represents the Terraform resource model for a role binding.
 type RoleBindingModel struct {
 	ID        types.String `tfsdk:"id"`
@@ -56,6 +100,9 @@ func metakubeRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"project_id": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -63,6 +110,9 @@ func metakubeRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"cluster_id": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -70,6 +120,9 @@ func metakubeRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"namespace": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -77,6 +130,9 @@ func metakubeRoleBindingAttributes() map[string]schema.Attribute {
 		},
 		"role_name": schema.StringAttribute{
 			Required: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
+			},
 			Validators: []validator.String{
 				stringvalidator.LengthAtLeast(1),
 			},
@@ -4,185 +4,297 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/syseleven/go-metakube/client/project"
 	"github.com/syseleven/go-metakube/models"
 	"github.com/syseleven/terraform-provider-metakube/metakube/common"
 )
 
-func MetakubeResourceSSHKey() *schema.Resource {
-	return &schema.Resource{
-		CreateContext: metakubeResourceSSHKeyCreate,
-		ReadContext:   metakubeResourceSSHKeyRead,
-		DeleteContext: metakubeResourceSSHKeyDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: common.ImportResourceWithOptionalProject("sshkey_id"),
-		},
+// readTimeout bounds how long Read waits for the project RBAC to allow listing SSH keys.
+const readTimeout = 20 * time.Minute
 
-		Schema: map[string]*schema.Schema{
-			"project_id": {
-				Type:         schema.TypeString,
-				Required:     true,
-				ValidateFunc: validation.NoZeroValues,
-				ForceNew:     true,
-			},
-
-			"name": {
-				Type:         schema.TypeString,
-				Required:     true,
-				ValidateFunc: validation.NoZeroValues,
-				ForceNew:     true,
-			},
-
-			"public_key": {
-				Type:         schema.TypeString,
-				Required:     true,
-				ValidateFunc: validation.NoZeroValues,
-				DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
-					return strings.TrimSpace(old) == strings.TrimSpace(new)
-				},
-				ForceNew: true,
-			},
-		},
+var (
+	_ resource.Resource                = &sshKeyResource{}
+	_ resource.ResourceWithConfigure   = &sshKeyResource{}
+	_ resource.ResourceWithImportState = &sshKeyResource{}
+	_ resource.ResourceWithIdentity    = &sshKeyResource{}
+	_ resource.ResourceWithModifyPlan  = &sshKeyResource{}
+)
+
+func NewSSHKey() resource.Resource {
+	return &sshKeyResource{}
+}
+
+type sshKeyResource struct {
+	meta *common.MetaKubeProviderMeta
+}
+
+func (r *sshKeyResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sshkey"
+}
+
+func (r *sshKeyResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = SSHKeySchema(ctx)
+}
+
+func (r *sshKeyResource) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = common.ProjectResourceIdentitySchema("SSH key identifier")
+}
+
+func (r *sshKeyResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
 	}
+
+	meta, ok := req.ProviderData.(*common.MetaKubeProviderMeta)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *common.MetaKubeProviderMeta, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.meta = meta
+}
+
+// ModifyPlan defaults project_id of new SSH keys to the project of the provider profile.
+func (r *sshKeyResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	resp.Diagnostics.Append(r.meta.PlanDefaultProjectID(ctx, req, resp)...)
 }
 
-func metakubeResourceSSHKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	k := m.(*common.MetaKubeProviderMeta)
-	p := project.NewCreateSSHKeyParams()
-	p.SetProjectID(d.Get("project_id").(string))
+func (r *sshKeyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemSSHKey)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("create", "SSH key"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var plan SSHKeyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p := project.NewCreateSSHKeyParams().
+		WithContext(ctx).
+		WithProjectID(plan.ProjectID.ValueString())
 	p.Key = &models.SSHKey{
-		Name: d.Get("name").(string),
+		Name: plan.Name.ValueString(),
 		Spec: &models.SSHKeySpec{
-			PublicKey: d.Get("public_key").(string),
+			PublicKey: plan.PublicKey.ValueString(),
 		},
 	}
-	created, err := k.Client.Project.CreateSSHKey(p, k.Auth)
+	created, err := r.meta.Client.Project.CreateSSHKey(p, r.meta.Auth)
 	if err != nil {
-		return diag.Errorf("unable to create SSH key: %s", common.StringifyResponseError(err))
+		resp.Diagnostics.AddError("Unable to create SSH key", common.StringifyResponseError(err))
+		return
 	}
-	d.SetId(created.Payload.ID)
-	return metakubeResourceSSHKeyRead(ctx, d, m)
-}
+	plan.ID = types.StringValue(created.Payload.ID)
 
-func metakubeResourceSSHKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	meta := m.(*common.MetaKubeProviderMeta)
-	sshkey, err := metakubeResourceSSHKeyFindByID(ctx, d, meta)
-	if err != nil {
-		return diag.FromErr(err)
+	resp.Diagnostics.Append(r.readIntoModel(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	if sshkey == nil {
-		d.SetId("")
-		return nil
+	if plan.ID.IsNull() {
+		resp.Diagnostics.AddError("SSH key not found", fmt.Sprintf("SSH key '%s' was created but could not be found", created.Payload.ID))
+		return
 	}
-	_ = d.Set("name", sshkey.Name)
-	_ = d.Set("public_key", sshkey.Spec.PublicKey)
-	return nil
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, sshKeyIdentity(&plan))...)
 }
 
-func metakubeResourceSSHKeyFindByID(ctx context.Context, d *schema.ResourceData, meta *common.MetaKubeProviderMeta) (*models.SSHKey, error) {
-	prj := d.Get("project_id").(string)
-	if prj == "" {
-		var err error
-		prj, err = metakubeResourceSSHKeyFindProjectID(ctx, d.Id(), meta)
-		if err != nil {
-			return nil, err
-		}
-		if prj == "" {
-			return nil, nil
-		}
-		_ = d.Set("project_id", prj)
+func (r *sshKeyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemSSHKey)
+
+	var state SSHKeyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	const pending, target = "Unavailable", "Ready"
-	listStateConf := &retry.StateChangeConf{
-		Pending: []string{
-			pending,
-		},
-		Target: []string{
-			target,
-		},
-		Refresh: func() (interface{}, string, error) {
-			prms := project.NewListSSHKeysParams().WithContext(ctx).WithProjectID(prj)
-			res, err := meta.Client.Project.ListSSHKeys(prms, meta.Auth)
-			if err != nil {
-				// wait for the RBACs
-				if _, ok := err.(*project.ListSSHKeysForbidden); ok {
-					return res, pending, nil
-				}
-				return nil, pending, fmt.Errorf("list ssh keys: %s", common.StringifyResponseError(err))
-			}
-			return res, target, nil
-		},
-		Timeout: d.Timeout(schema.TimeoutRead),
-		Delay:   common.RequestDelay,
+	resp.Diagnostics.Append(r.readIntoModel(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	s, err := listStateConf.WaitForStateContext(ctx)
-	if err != nil {
-		meta.Log.Debugf("error while waiting for the SSH keys: %v", err)
-		return nil, fmt.Errorf("error while waiting for the SSH keys: %v", err)
+	if state.ID.IsNull() {
+		resp.State.RemoveResource(ctx)
+		return
 	}
-	keys := s.(*project.ListSSHKeysOK)
 
-	for _, r := range keys.Payload {
-		if r.ID == d.Id() {
-			return r, nil
-		}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, sshKeyIdentity(&state))...)
+}
+
+// Update only stores whitespace changes of public_key, every other change replaces the key.
+func (r *sshKeyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan SSHKeyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	return nil, nil
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, sshKeyIdentity(&plan))...)
 }
 
-func metakubeResourceSSHKeyFindProjectID(ctx context.Context, id string, meta *common.MetaKubeProviderMeta) (string, error) {
-	r, err := meta.Client.Project.ListProjects(project.NewListProjectsParams(), meta.Auth)
-	if err != nil {
-		return "", fmt.Errorf("list projects: %v", err)
+func (r *sshKeyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemSSHKey)
+
+	resp.Diagnostics.Append(common.ReadOnlyToFrameworkDiagnostics(r.meta.CheckWritable("delete", "SSH key"))...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state SSHKeyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	for _, project := range r.Payload {
-		ok, err := metakubeResourceSSHKeyBelongsToProject(ctx, project.ID, id, meta)
-		if ok {
-			return project.ID, nil
+	p := project.NewDeleteSSHKeyParams().
+		WithContext(ctx).
+		WithProjectID(state.ProjectID.ValueString()).
+		WithSSHKeyID(state.ID.ValueString())
+	if _, err := r.meta.Client.Project.DeleteSSHKey(p, r.meta.Auth); err != nil {
+		resp.Diagnostics.AddError("Unable to delete SSH key", common.StringifyResponseError(err))
+	}
+}
+
+// ImportState accepts an identity or the legacy import IDs 'project_id:sshkey_id'
+// and 'sshkey_id'. Read looks up the project when it is not given.
+func (r *sshKeyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	var projectID, id string
+	if req.ID == "" {
+		var identity common.ProjectResourceIdentityModel
+		resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+		if resp.Diagnostics.HasError() {
+			return
 		}
-		if err != nil {
-			return "", err
+		projectID = identity.ProjectID.ValueString()
+		id = identity.ID.ValueString()
+	} else {
+		parts := strings.Split(req.ID, ":")
+		switch len(parts) {
+		case 1:
+			id = parts[0]
+		case 2:
+			projectID = parts[0]
+			id = parts[1]
+		default:
+			resp.Diagnostics.AddError(
+				"Invalid import ID",
+				"please provide resource identifier in format 'project_id:sshkey_id' or 'sshkey_id'",
+			)
+			return
 		}
 	}
 
-	meta.Log.Infof("owner project for service account with id(%s) not found", id)
-	return "", nil
+	if projectID != "" {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+func sshKeyIdentity(model *SSHKeyModel) *common.ProjectResourceIdentityModel {
+	return &common.ProjectResourceIdentityModel{
+		ProjectID: model.ProjectID,
+		ID:        model.ID,
+	}
 }
 
-func metakubeResourceSSHKeyBelongsToProject(ctx context.Context, prj, id string, meta *common.MetaKubeProviderMeta) (bool, error) {
-	prms := project.NewListSSHKeysParams().WithContext(ctx).WithProjectID(prj)
-	r, err := meta.Client.Project.ListSSHKeys(prms, meta.Auth)
+// readIntoModel refreshes the model from the API. The ID is set to null when
+// the SSH key no longer exists.
+func (r *sshKeyResource) readIntoModel(ctx context.Context, model *SSHKeyModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	id := model.ID.ValueString()
+	projectID := model.ProjectID.ValueString()
+	if projectID == "" {
+		var err error
+		projectID, err = r.findProjectID(ctx, id)
+		if err != nil {
+			diags.AddError("Failed to find project", err.Error())
+			return diags
+		}
+		if projectID == "" {
+			model.ID = types.StringNull()
+			return diags
+		}
+	}
+
+	keys, err := r.listSSHKeys(ctx, projectID)
 	if err != nil {
-		return false, fmt.Errorf("list sshkeys: %s", common.StringifyResponseError(err))
+		diags.AddError("Unable to list SSH keys", err.Error())
+		return diags
 	}
 
-	for _, i := range r.Payload {
-		if i.ID == id {
-			return true, nil
+	for _, key := range keys {
+		if key.ID != id {
+			continue
 		}
+
+		model.ProjectID = types.StringValue(projectID)
+		model.Name = types.StringValue(key.Name)
+		if key.Spec != nil && strings.TrimSpace(key.Spec.PublicKey) != strings.TrimSpace(model.PublicKey.ValueString()) {
+			model.PublicKey = types.StringValue(key.Spec.PublicKey)
+		}
+		return diags
 	}
 
-	return false, nil
+	r.meta.Log.Infof(ctx, "removing SSH key '%s', could not find the resource", id)
+	model.ID = types.StringNull()
+	return diags
+}
+
+// listSSHKeys lists the SSH keys of a project, waiting while the RBAC of a new project still forbids it.
+func (r *sshKeyResource) listSSHKeys(ctx context.Context, projectID string) ([]*models.SSHKey, error) {
+	deadline := time.Now().Add(readTimeout)
+	for {
+		p := project.NewListSSHKeysParams().WithContext(ctx).WithProjectID(projectID)
+		res, err := r.meta.Client.Project.ListSSHKeys(p, r.meta.Auth)
+		if err == nil {
+			return res.Payload, nil
+		}
+		if _, ok := err.(*project.ListSSHKeysForbidden); !ok || time.Now().After(deadline) {
+			return nil, fmt.Errorf("list ssh keys: %s", common.StringifyResponseError(err))
+		}
+
+		r.meta.Log.Debugf(ctx, "listing SSH keys of project '%s' forbidden, retrying", projectID)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(common.RequestDelay):
+		}
+	}
 }
 
-func metakubeResourceSSHKeyDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	k := m.(*common.MetaKubeProviderMeta)
-	p := project.NewDeleteSSHKeyParams()
-	p.SetContext(ctx)
-	p.SetProjectID(d.Get("project_id").(string))
-	p.SetSSHKeyID(d.Id())
-	_, err := k.Client.Project.DeleteSSHKey(p, k.Auth)
+func (r *sshKeyResource) findProjectID(ctx context.Context, id string) (string, error) {
+	res, err := r.meta.Client.Project.ListProjects(project.NewListProjectsParams().WithContext(ctx), r.meta.Auth)
 	if err != nil {
-		return diag.Errorf("unable to delete SSH key: %s", common.StringifyResponseError(err))
+		return "", fmt.Errorf("list projects: %v", err)
+	}
+
+	for _, prj := range res.Payload {
+		p := project.NewListSSHKeysParams().WithContext(ctx).WithProjectID(prj.ID)
+		keys, err := r.meta.Client.Project.ListSSHKeys(p, r.meta.Auth)
+		if err != nil {
+			return "", fmt.Errorf("list sshkeys: %s", common.StringifyResponseError(err))
+		}
+		for _, key := range keys.Payload {
+			if key.ID == id {
+				return prj.ID, nil
+			}
+		}
 	}
-	return nil
+
+	r.meta.Log.Infof(ctx, "owner project for SSH key with id '%s' not found", id)
+	return "", nil
 }
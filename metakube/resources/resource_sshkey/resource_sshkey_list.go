@@ -0,0 +1,125 @@
+package resource_sshkey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/syseleven/go-metakube/models"
+	"github.com/syseleven/terraform-provider-metakube/metakube/common"
+)
+
+var (
+	_ list.ListResource              = &sshKeyResource{}
+	_ list.ListResourceWithConfigure = &sshKeyResource{}
+)
+
+func NewSSHKeyListResource() list.ListResource {
+	return &sshKeyResource{}
+}
+
+type sshKeyListModel struct {
+	ProjectID types.String `tfsdk:"project_id"`
+	Name      types.String `tfsdk:"name"`
+}
+
+func (r *sshKeyResource) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists SSH keys, for example to generate import blocks with terraform query",
+		Attributes: map[string]schema.Attribute{
+			"project_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Project to list SSH keys of. Defaults to the project of the provider profile, otherwise SSH keys of all projects are listed",
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list SSH keys with this name",
+			},
+		},
+	}
+}
+
+func (r *sshKeyResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	if r.meta == nil {
+		stream.Results = list.ListResultsStreamDiagnostics(common.UnconfiguredProviderDiagnostics())
+		return
+	}
+	ctx = r.meta.Log.WithSubsystem(ctx, common.LogSubsystemSSHKey)
+
+	var config sshKeyListModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	projectIDs, err := common.MetakubeListProjectIDs(ctx, config.ProjectID.ValueString(), r.meta)
+	if err != nil {
+		diags.AddError("Unable to list projects", err.Error())
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, projectID := range projectIDs {
+			keys, err := r.listSSHKeys(ctx, projectID)
+			if err != nil {
+				result := req.NewListResult(ctx)
+				result.Diagnostics.AddError("Unable to list SSH keys", fmt.Sprintf("Unable to list SSH keys of project '%s': %s", projectID, err))
+				push(result)
+				return
+			}
+
+			for _, key := range keys {
+				if key == nil {
+					continue
+				}
+				if name := config.Name.ValueString(); name != "" && key.Name != name {
+					continue
+				}
+				if !push(r.sshKeyListResult(ctx, req, projectID, key)) {
+					return
+				}
+				count++
+				if req.Limit > 0 && count >= req.Limit {
+					return
+				}
+			}
+		}
+	}
+}
+
+// sshKeyListResult returns the identity of a listed SSH key and, when
+// requested, reads the full resource the same way an import does.
+func (r *sshKeyResource) sshKeyListResult(ctx context.Context, req list.ListRequest, projectID string, key *models.SSHKey) list.ListResult {
+	result := req.NewListResult(ctx)
+	result.DisplayName = key.Name
+
+	result.Diagnostics.Append(result.Identity.Set(ctx, &common.ProjectResourceIdentityModel{
+		ProjectID: types.StringValue(projectID),
+		ID:        types.StringValue(key.ID),
+	})...)
+	if !req.IncludeResource || result.Diagnostics.HasError() {
+		return result
+	}
+
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+	result.Diagnostics.Append(result.Resource.SetAttribute(ctx, path.Root("id"), key.ID)...)
+	var model SSHKeyModel
+	result.Diagnostics.Append(result.Resource.Get(ctx, &model)...)
+	if result.Diagnostics.HasError() {
+		return result
+	}
+
+	result.Diagnostics.Append(r.readIntoModel(ctx, &model)...)
+	if result.Diagnostics.HasError() {
+		return result
+	}
+	result.Diagnostics.Append(result.Resource.Set(ctx, &model)...)
+
+	return result
+}